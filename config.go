@@ -9,36 +9,50 @@ import (
 
 // Config 配置结构体，包含多个配置选项，用户可以根据需要自定义这些选项
 type Config struct {
-	JSONEncoder          func(v interface{}) ([]byte, error)    // 自定义 JSON 编码器，默认使用标准库的 json.Marshal
-	JSONDecoder          func(data []byte, v interface{}) error // 自定义 JSON 解码器，默认使用标准库的 json.Unmarshal
-	ShowBanner           bool                                   // 是否在启动时显示欢迎横幅，默认显示
-	PrintRoutes          bool                                   // 是否在启动时打印所有已注册的路由信息，默认打印
-	ServerHeader         string                                 // 设置服务器响应头的 Server 字段，默认为 "KangGo"
-	IdleTimeout          time.Duration                          // 服务器空闲连接的超时时间
-	ReadTimeout          time.Duration                          // 服务器读取请求的超时时间
-	WriteTimeout         time.Duration                          // 服务器写入响应的超时时间
-	MaxRequestBodySize   int                                    // 最大请求体大小，默认为 4 MB
-	CaseSensitiveRouting bool                                   // 路由是否区分大小写，默认区分
-	StrictRouting        bool                                   // 是否启用严格路由模式，默认不启用
-	UnescapePath         bool                                   // 是否对 URL 路径进行解码处理，默认不处理
+	JSONEncoder           func(v interface{}) ([]byte, error)    // 自定义 JSON 编码器，默认使用标准库的 json.Marshal
+	JSONDecoder           func(data []byte, v interface{}) error // 自定义 JSON 解码器，默认使用标准库的 json.Unmarshal
+	Renderers             map[string]Renderer                    // 按 MIME 类型注册的响应渲染器，未注册的类型使用内置实现
+	Decoders              map[string]Decoder                     // 按 MIME 类型注册的请求体解码器，未注册的类型使用内置实现
+	ShowBanner            bool                                   // 是否在启动时显示欢迎横幅，默认显示
+	PrintRoutes           bool                                   // 是否在启动时打印所有已注册的路由信息，默认打印
+	ServerHeader          string                                 // 设置服务器响应头的 Server 字段，默认为 "KangGo"
+	IdleTimeout           time.Duration                          // 服务器空闲连接的超时时间
+	ReadTimeout           time.Duration                          // 服务器读取请求的超时时间
+	WriteTimeout          time.Duration                          // 服务器写入响应的超时时间
+	MaxRequestBodySize    int                                    // 最大请求体大小，默认为 4 MB
+	CaseSensitiveRouting  bool                                   // 路由是否区分大小写，默认区分
+	StrictRouting         bool                                   // 是否启用严格路由模式，默认不启用
+	UnescapePath          bool                                   // 是否对 URL 路径进行解码处理，默认不处理
+	ErrorHandler          ErrorHandler                           // 处理函数返回错误时调用，默认使用 DefaultErrorHandler
+	RedirectAllowedHosts  []string                               // Redirect 允许跳转的外部主机，支持 "*.example.com"；相对地址与当前主机始终允许
+	RedirectCanonicalPath bool                                   // 未匹配到路由时，是否重定向到规范路径（非严格路由去掉末尾斜杠、不区分大小写时转为小写），默认不重定向
+	CookieKeys            []string                               // SetSignedCookie 使用的 HMAC 密钥，第一个用于签名，全部用于验证，便于密钥轮换
+	Views                 TemplateEngine                         // ctx.Render 使用的模板引擎，Run 启动时自动调用其 Load 方法
 }
 
 // DefaultConfig 返回默认的配置
 // 这是框架提供的默认配置，如果用户不提供自定义配置，则使用此配置
 func DefaultConfig() Config {
 	return Config{
-		JSONEncoder:          json.Marshal,    // 使用标准库的 JSON 编码器
-		JSONDecoder:          json.Unmarshal,  // 使用标准库的 JSON 解码器
-		ShowBanner:           true,            // 启动时显示欢迎横幅
-		PrintRoutes:          true,            // 启动时打印路由信息
-		ServerHeader:         "KangGo",        // 设置默认的服务器响应头
-		IdleTimeout:          0,               // 默认不设置空闲超时
-		ReadTimeout:          0,               // 默认不设置读取超时
-		WriteTimeout:         0,               // 默认不设置写入超时
-		MaxRequestBodySize:   4 * 1024 * 1024, // 最大请求体大小为 4 MB
-		CaseSensitiveRouting: false,           // 路由区分大小写
-		StrictRouting:        false,           // 不启用严格路由模式
-		UnescapePath:         false,           // 不对 URL 路径进行解码处理
+		JSONEncoder:           json.Marshal,        // 使用标准库的 JSON 编码器
+		JSONDecoder:           json.Unmarshal,      // 使用标准库的 JSON 解码器
+		Renderers:             DefaultRenderers(),  // 使用内置的 XML、YAML、MessagePack、Protobuf 渲染器
+		Decoders:              DefaultDecoders(),   // 使用内置的 XML、YAML、MessagePack、Protobuf 解码器
+		ShowBanner:            true,                // 启动时显示欢迎横幅
+		PrintRoutes:           true,                // 启动时打印路由信息
+		ServerHeader:          "KangGo",            // 设置默认的服务器响应头
+		IdleTimeout:           0,                   // 默认不设置空闲超时
+		ReadTimeout:           0,                   // 默认不设置读取超时
+		WriteTimeout:          0,                   // 默认不设置写入超时
+		MaxRequestBodySize:    4 * 1024 * 1024,     // 最大请求体大小为 4 MB
+		CaseSensitiveRouting:  false,               // 路由区分大小写
+		StrictRouting:         false,               // 不启用严格路由模式
+		UnescapePath:          false,               // 不对 URL 路径进行解码处理
+		ErrorHandler:          DefaultErrorHandler, // 使用默认的错误处理函数
+		RedirectAllowedHosts:  nil,                 // 只允许相对地址与当前主机
+		RedirectCanonicalPath: false,               // 不重定向到规范路径
+		CookieKeys:            nil,                 // 默认不配置签名密钥
+		Views:                 nil,                 // 默认不配置模板引擎
 	}
 }
 
@@ -124,4 +124,14 @@ const (
 	HeaderXRequestedWith                  = "X-Requested-With"                    // HTTP 请求头字段，指示请求是由 Ajax 发起的
 	HeaderXRobotsTag                      = "X-Robots-Tag"                        // HTTP 响应头字段，控制搜索引擎抓取行为
 	HeaderXUACompatible                   = "X-UA-Compatible"                     // HTTP 响应头字段，指定浏览器的兼容性模式
+	HeaderHXRequest                       = "HX-Request"                          // HTMX 请求头字段，由 htmx 发起的请求值为 "true"
+	HeaderHXBoosted                       = "HX-Boosted"                          // HTMX 请求头字段，通过 hx-boost 发起的请求值为 "true"
+	HeaderHXTarget                        = "HX-Target"                           // HTMX 请求头字段，目标元素的 id
+	HeaderHXCurrentURL                    = "HX-Current-URL"                      // HTMX 请求头字段，浏览器当前的地址
+	HeaderHXRedirect                      = "HX-Redirect"                         // HTMX 响应头字段，让客户端整页跳转到指定地址
+	HeaderHXRefresh                       = "HX-Refresh"                          // HTMX 响应头字段，值为 "true" 时客户端刷新整个页面
+	HeaderHXPushURL                       = "HX-Push-Url"                         // HTMX 响应头字段，将地址写入浏览器历史记录
+	HeaderHXReswap                        = "HX-Reswap"                           // HTMX 响应头字段，覆盖 hx-swap 指定的交换方式
+	HeaderHXRetarget                      = "HX-Retarget"                         // HTMX 响应头字段，覆盖更新目标的 CSS 选择器
+	HeaderHXTrigger                       = "HX-Trigger"                          // HTMX 请求/响应头字段，请求中为触发元素的 id，响应中用于在客户端触发事件
 )
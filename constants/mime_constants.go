@@ -11,6 +11,15 @@ const (
 	MIMEApplicationForm       = "application/x-www-form-urlencoded" // 表单 URL 编码格式
 	MIMEOctetStream           = "application/octet-stream"          // 二进制流数据（任意文件类型）
 	MIMEMultipartForm         = "multipart/form-data"               // 多部分表单数据格式（用于文件上传）
+	MIMEApplicationYAML       = "application/yaml"                  // YAML 应用程序格式
+	MIMEApplicationXYAML      = "application/x-yaml"                // YAML 应用程序格式（旧式写法）
+	MIMETextYAML              = "text/yaml"                         // YAML 文本格式
+	MIMEApplicationMsgPack    = "application/vnd.msgpack"           // MessagePack 二进制格式
+	MIMEApplicationXMsgPack   = "application/x-msgpack"             // MessagePack 二进制格式（旧式写法）
+	MIMEApplicationProtobuf   = "application/x-protobuf"            // Protocol Buffers 二进制格式
+	MIMEApplicationNDJSON     = "application/x-ndjson"              // 换行分隔的 JSON 流（NDJSON）
+	MIMEApplicationJSONSeq    = "application/json-seq"              // JSON 文本序列（RFC 7464）
+	MIMETextEventStream       = "text/event-stream"                 // Server-Sent Events 事件流
 
 	// MIMETextXMLCharsetUTF8 定义常见的带有 UTF-8 字符集的 MIME 类型常量
 	MIMETextXMLCharsetUTF8               = "text/xml; charset=utf-8"               // XML 文本格式，UTF-8 字符集
@@ -19,4 +28,5 @@ const (
 	MIMEApplicationXMLCharsetUTF8        = "application/xml; charset=utf-8"        // XML 应用程序格式，UTF-8 字符集
 	MIMEApplicationJSONCharsetUTF8       = "application/json; charset=utf-8"       // JSON 应用程序格式，UTF-8 字符集
 	MIMEApplicationJavaScriptCharsetUTF8 = "application/javascript; charset=utf-8" // JavaScript 应用程序格式，UTF-8 字符集
+	MIMEApplicationYAMLCharsetUTF8       = "application/yaml; charset=utf-8"       // YAML 应用程序格式，UTF-8 字符集
 )
@@ -5,35 +5,114 @@ import (
 	"github.com/7836246/kanggo/constants"
 	"io"
 	"net/http"
-	"os"
 	"reflect"
 	"strconv"
 )
 
+// maxParams 是 Context 内置的路径参数存储容量，超出后才会额外分配内存
+const maxParams = 8
+
+// Param 表示一个路径参数
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 是按路由中出现顺序排列的路径参数列表
+type Params []Param
+
+// Get 返回指定名称的路径参数及其是否存在
+func (ps Params) Get(key string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Map 将路径参数复制到新的 map 中，供仍按 map 使用路径参数的旧代码迁移时使用
+// 每次调用都会分配内存，读取单个参数请使用 Get 或 Context.Param
+func (ps Params) Map() map[string]string {
+	m := make(map[string]string, len(ps))
+	for _, p := range ps {
+		m[p.Key] = p.Value
+	}
+	return m
+}
+
 // Context 代表 HTTP 请求的上下文
+// 由 Router 创建的 Context 来自对象池，处理函数返回后会被回收复用，不能在处理函数之外继续持有
 type Context struct {
 	Writer         http.ResponseWriter
 	Request        *http.Request
-	Params         map[string]string
-	jsonEncoder    func(v interface{}) ([]byte, error)
-	jsonDecoder    func(data []byte, v interface{}) error
-	TemplateEngine TemplateEngine
+	Params         Params
+	TemplateEngine TemplateEngine // 当前请求使用的模板引擎，默认为 Config.Views，可在中间件中按请求替换
+	contextConfig
+
+	locals     *localStore      // 通过 Set 保存的请求范围内的值
+	paramStore [maxParams]Param // Params 的底层存储，避免每次请求分配
+	writer     contextWriter    // 传给中间件链的 ResponseWriter，链末端据此找回 Context
+	path       string           // 用于路由匹配的请求路径
+}
+
+// contextConfig 是 Context 中来自应用配置、在复用期间保持不变的字段
+// 集中在一个结构体中，detach 整体复制，新增字段时无需同步修改
+type contextConfig struct {
+	jsonEncoder   func(v interface{}) ([]byte, error)
+	jsonDecoder   func(data []byte, v interface{}) error
+	renderers     map[string]Renderer
+	decoders      map[string]Decoder
+	errorHandler  ErrorHandler
+	router        *Router
+	redirectHosts []string
+	cookieKeys    []string
+	views         TemplateEngine
 }
 
 // NewContext 创建一个新的 Context 实例
 func NewContext(w http.ResponseWriter, req *http.Request, cfg Config) *Context {
-	return &Context{
-		Writer:      w,
-		Request:     req,
-		Params:      make(map[string]string),
-		jsonEncoder: cfg.JSONEncoder,
-		jsonDecoder: cfg.JSONDecoder,
-	}
+	c := &Context{}
+	c.init(cfg)
+	c.reset(w, req)
+	return c
+}
+
+// init 设置 Context 中与配置相关、在复用期间保持不变的字段
+func (c *Context) init(cfg Config) {
+	c.jsonEncoder = cfg.JSONEncoder
+	c.jsonDecoder = cfg.JSONDecoder
+	c.renderers = cfg.Renderers
+	c.decoders = cfg.Decoders
+	c.errorHandler = cfg.ErrorHandler
+	c.redirectHosts = cfg.RedirectAllowedHosts
+	c.cookieKeys = cfg.CookieKeys
+	c.views = cfg.Views
+	c.writer.ctx = c
+}
+
+// reset 为新的请求重置 Context
+// Writer 指向内置的 contextWriter，以便记录状态码与响应大小
+func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
+	c.writer.Reset(w)
+	c.Writer = &c.writer
+	c.Request = req
+	c.Params = c.paramStore[:0]
+	c.TemplateEngine = c.views
+	c.locals = nil
+	c.path = ""
+}
+
+// release 清理对请求的引用，以便 Context 放回对象池
+func (c *Context) release() {
+	clear(c.paramStore[:])
+	c.reset(nil, nil)
 }
 
 // Param 获取路径参数
 func (c *Context) Param(key string) string {
-	return c.Params[key]
+	value, _ := c.Params.Get(key)
+	return value
 }
 
 // Query 获取 URL 查询参数
@@ -67,8 +146,56 @@ func (c *Context) BindJSON(obj interface{}) error {
 	if c.jsonDecoder == nil {
 		return json.NewDecoder(c.Request.Body).Decode(obj)
 	}
-	decoder := json.NewDecoder(c.Request.Body)
-	return decoder.Decode(obj)
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	return c.jsonDecoder(data, obj)
+}
+
+// BindXML 解析 XML 请求体到指定的对象
+func (c *Context) BindXML(obj interface{}) error {
+	return c.BindWith(constants.MIMEApplicationXML, obj)
+}
+
+// BindYAML 解析 YAML 请求体到指定的对象
+func (c *Context) BindYAML(obj interface{}) error {
+	return c.BindWith(constants.MIMEApplicationYAML, obj)
+}
+
+// BindMsgPack 解析 MessagePack 请求体到指定的对象
+func (c *Context) BindMsgPack(obj interface{}) error {
+	return c.BindWith(constants.MIMEApplicationMsgPack, obj)
+}
+
+// BindProtoBuf 解析 Protobuf 请求体到指定的对象，obj 需实现 proto.Message
+func (c *Context) BindProtoBuf(obj interface{}) error {
+	return c.BindWith(constants.MIMEApplicationProtobuf, obj)
+}
+
+// BindWith 使用指定 MIME 类型的解码器解析请求体
+func (c *Context) BindWith(mimeType string, obj interface{}) error {
+	decode, err := c.decoder(mimeType)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	return decode(data, obj)
+}
+
+// Bind 根据请求头 Content-Type 选择合适的解码器解析请求体
+func (c *Context) Bind(obj interface{}) error {
+	switch mimeType := mediaType(c.Request.Header.Get(constants.HeaderContentType)); mimeType {
+	case constants.MIMEApplicationJSON:
+		return c.BindJSON(obj)
+	case constants.MIMEApplicationForm, constants.MIMEMultipartForm:
+		return c.BindForm(obj)
+	default:
+		return c.BindWith(mimeType, obj)
+	}
 }
 
 // BindForm 解析表单数据到指定的结构体
@@ -120,7 +247,11 @@ func (c *Context) BindForm(obj interface{}) error {
 
 // JSON 返回一个 JSON 响应
 func (c *Context) JSON(code int, obj interface{}) error {
-	data, err := c.jsonEncoder(obj)
+	encode := c.jsonEncoder
+	if encode == nil {
+		encode = json.Marshal
+	}
+	data, err := encode(obj)
 	if err != nil {
 		return err
 	}
@@ -141,6 +272,40 @@ func (c *Context) JSONP(callback string, obj interface{}) error {
 	return err
 }
 
+// XML 返回一个 XML 响应
+func (c *Context) XML(code int, obj interface{}) error {
+	return c.Encode(code, constants.MIMEApplicationXMLCharsetUTF8, obj)
+}
+
+// YAML 返回一个 YAML 响应
+func (c *Context) YAML(code int, obj interface{}) error {
+	return c.Encode(code, constants.MIMEApplicationYAMLCharsetUTF8, obj)
+}
+
+// MsgPack 返回一个 MessagePack 响应
+func (c *Context) MsgPack(code int, obj interface{}) error {
+	return c.Encode(code, constants.MIMEApplicationMsgPack, obj)
+}
+
+// ProtoBuf 返回一个 Protobuf 响应，obj 需实现 proto.Message
+func (c *Context) ProtoBuf(code int, obj interface{}) error {
+	return c.Encode(code, constants.MIMEApplicationProtobuf, obj)
+}
+
+// Encode 使用 Config.Renderers 中对应 contentType 的渲染器编码对象并返回响应
+// contentType 可以带有参数（如 charset），查找渲染器时会忽略参数部分
+func (c *Context) Encode(code int, contentType string, obj interface{}) error {
+	render, err := c.renderer(contentType)
+	if err != nil {
+		return err
+	}
+	data, err := render(obj)
+	if err != nil {
+		return err
+	}
+	return c.send(code, contentType, data)
+}
+
 // SendString 返回一个纯文本响应
 func (c *Context) SendString(msg string) error {
 	c.Writer.Header().Set(constants.HeaderContentType, constants.MIMETextPlain) // 使用常量
@@ -162,48 +327,3 @@ func (c *Context) SendError(code int, message string) error {
 	_, err := c.Writer.Write([]byte(message))
 	return err
 }
-
-// SendFile 发送文件作为响应
-func (c *Context) SendFile(filepath string, download bool) error {
-	// 检查文件是否存在
-	file, err := os.Open(filepath)
-	if err != nil {
-		http.Error(c.Writer, "文件未找到", http.StatusNotFound)
-		return err
-	}
-	defer func(file *os.File) {
-		err = file.Close()
-	}(file)
-
-	// 获取文件信息
-	fileInfo, err := file.Stat()
-	if err != nil {
-		http.Error(c.Writer, "服务器错误", http.StatusInternalServerError)
-		return err
-	}
-
-	// 设置响应头的 Content-Type
-	mimeType := http.DetectContentType(make([]byte, 512))
-	c.Writer.Header().Set(constants.HeaderContentType, mimeType)
-
-	// 设置 Content-Disposition 头以启用下载
-	if download {
-		c.Writer.Header().Set("Content-Disposition", "attachment; filename=\""+fileInfo.Name()+"\"")
-	}
-
-	// 设置 Content-Length 头
-	c.Writer.Header().Set("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
-
-	// 发送文件内容
-	_, err = io.Copy(c.Writer, file)
-	return err
-}
-
-// Render 渲染模板
-func (c *Context) Render(name string, data interface{}) error {
-	if c.TemplateEngine == nil {
-		http.Error(c.Writer, "未配置模板引擎", http.StatusInternalServerError)
-		return nil
-	}
-	return c.TemplateEngine.Render(c.Writer, name, data)
-}
@@ -0,0 +1,46 @@
+package kanggo
+
+import (
+	"net/http"
+
+	"github.com/7836246/kanggo/core"
+)
+
+// contextWriter 是 Router 传给中间件链的 ResponseWriter，链末端通过它找回所属的 Context
+// 中间件链只在注册中间件时构建一次，Context 不再通过每个请求新建的闭包传递
+// 嵌入的 core.ResponseWriter 负责记录状态码与响应大小，供 Context.Status 与 Context.Size 使用
+type contextWriter struct {
+	core.ResponseWriter
+	ctx *Context
+}
+
+// contextFromWriter 沿 Unwrap 链查找 Router 创建的 contextWriter 并返回其 Context
+// 中间件包装 ResponseWriter 时未实现 Unwrap 则返回 nil
+func contextFromWriter(w http.ResponseWriter) *Context {
+	for {
+		switch v := w.(type) {
+		case *contextWriter:
+			return v.ctx
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// Status 返回已经发送给客户端的状态码，尚未发送时返回 200
+// 缓冲响应的中间件（如 etag）在处理函数返回后才会真正发送，此时可在外层中间件中读取
+func (c *Context) Status() int {
+	return c.writer.Status()
+}
+
+// Size 返回已经发送给客户端的响应体字节数
+func (c *Context) Size() int64 {
+	return c.writer.Size()
+}
+
+// Written 返回响应头是否已经发送
+func (c *Context) Written() bool {
+	return c.writer.Written()
+}
@@ -0,0 +1,38 @@
+package kanggo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 测试中间件在处理函数返回后读取状态码与响应大小
+func TestContextStatusSize(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	var status int
+	var size int64
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r)
+			ctx := ContextOf(w)
+			status, size = ctx.Status(), ctx.Size()
+		}
+	})
+	router.Handle(http.MethodGet, "/created", func(ctx *Context) error {
+		if ctx.Written() {
+			t.Error("写入前 Written 应为 false")
+		}
+		ctx.Writer.WriteHeader(http.StatusCreated)
+		return ctx.SendString("created")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/created", nil))
+	if status != http.StatusCreated || size != int64(len("created")) {
+		t.Errorf("记录错误: 状态码 %v, 大小 %v", status, size)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if status != http.StatusNotFound {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,141 @@
+package kanggo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+var (
+	// ErrNoCookieKeys 表示使用签名 Cookie 前没有配置 Config.CookieKeys
+	ErrNoCookieKeys = errors.New("kanggo: 未配置 Cookie 签名密钥")
+	// ErrInvalidCookieSignature 表示 Cookie 的签名不正确，可能被篡改或密钥已经失效
+	ErrInvalidCookieSignature = errors.New("kanggo: Cookie 签名无效")
+)
+
+// Cookie 返回请求中指定名称的 Cookie 值，不存在时返回 http.ErrNoCookie
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// SetCookie 设置响应 Cookie，并补充安全的默认值：
+// 总是设置 HttpOnly，Path 为空时使用 "/"，SameSite 未设置时使用 Lax，HTTPS 请求自动设置 Secure
+// 需要被 JavaScript 读取的 Cookie（例如 CSRF 双重提交令牌）请使用 SetScriptCookie
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	c.setCookie(cookie, true)
+}
+
+// SetScriptCookie 与 SetCookie 相同，但不设置 HttpOnly，Cookie 可以被页面中的脚本读取
+// 脚本可读意味着 XSS 漏洞也能读取，不要用于会话标识等敏感的值
+func (c *Context) SetScriptCookie(cookie *http.Cookie) {
+	c.setCookie(cookie, false)
+}
+
+// setCookie 复制 Cookie 并补充默认值后写入响应头，httpOnly 覆盖调用方的 HttpOnly 设置
+func (c *Context) setCookie(cookie *http.Cookie, httpOnly bool) {
+	cookie = cloneCookie(cookie)
+	cookie.HttpOnly = httpOnly
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 { // 零值表示未设置，SameSiteDefaultMode 会输出不带值的 SameSite
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	if c.isTLS() {
+		cookie.Secure = true
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+// ClearCookie 通知客户端删除指定名称、Path 为 "/" 的 Cookie
+// 设置时使用了其他 Path 或 Domain 的 Cookie 请使用 ExpireCookie
+func (c *Context) ClearCookie(names ...string) {
+	for _, name := range names {
+		c.ExpireCookie(&http.Cookie{Name: name})
+	}
+}
+
+// ExpireCookie 通知客户端删除 Cookie，浏览器按名称、Path 与 Domain 识别 Cookie，
+// 因此传入的 Cookie 必须与设置时的 Path、Domain 一致，Value 与过期时间会被覆盖
+func (c *Context) ExpireCookie(cookie *http.Cookie) {
+	cookie = cloneCookie(cookie)
+	cookie.Value = ""
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	c.SetCookie(cookie)
+}
+
+// SetSignedCookie 使用 Config.CookieKeys 中的第一个密钥对 Cookie 值进行 HMAC-SHA256 签名后设置
+// 签名 Cookie 只供服务端验证，与 SetCookie 一样总是设置 HttpOnly；签名只防止篡改，值本身仍然可以被客户端读取；需要保密的值请使用 encryptcookie 中间件
+func (c *Context) SetSignedCookie(cookie *http.Cookie) error {
+	if len(c.cookieKeys) == 0 {
+		return ErrNoCookieKeys
+	}
+	signed := cloneCookie(cookie)
+	signed.Value = base64.RawURLEncoding.EncodeToString([]byte(cookie.Value)) + "." +
+		base64.RawURLEncoding.EncodeToString(signCookie(c.cookieKeys[0], cookie.Name, cookie.Value))
+	c.SetCookie(signed)
+	return nil
+}
+
+// SignedCookie 返回经过签名验证的 Cookie 值
+// 依次尝试 Config.CookieKeys 中的所有密钥，因此轮换密钥时旧密钥签名的 Cookie 仍然有效
+func (c *Context) SignedCookie(name string) (string, error) {
+	if len(c.cookieKeys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	raw, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	encodedValue, encodedSignature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return "", ErrInvalidCookieSignature
+	}
+	value, err := base64.RawURLEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return "", ErrInvalidCookieSignature
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", ErrInvalidCookieSignature
+	}
+
+	for _, key := range c.cookieKeys {
+		if hmac.Equal(signature, signCookie(key, name, string(value))) {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookieSignature
+}
+
+// cloneCookie 复制 Cookie，避免修改调用方传入的对象
+func cloneCookie(cookie *http.Cookie) *http.Cookie {
+	clone := *cookie
+	return &clone
+}
+
+// signCookie 计算 Cookie 的签名，签名同时覆盖名称与值，防止把一个 Cookie 的值挪用到另一个 Cookie
+func signCookie(key, name, value string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(name))
+	mac.Write([]byte{'='})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// isTLS 判断请求是否通过 HTTPS 到达，包括经由反向代理转发的 HTTPS 请求
+func (c *Context) isTLS() bool {
+	return c.Request.TLS != nil || strings.EqualFold(c.Request.Header.Get(constants.HeaderXForwardedProto), "https")
+}
@@ -0,0 +1,107 @@
+package kanggo
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试 SetCookie 的默认值、SetScriptCookie、Cookie 与 ClearCookie
+func TestContextCookie(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	req.AddCookie(&http.Cookie{Name: "lang", Value: "zh"})
+	resp := httptest.NewRecorder()
+	ctx := NewContext(resp, req, DefaultConfig())
+
+	if value, err := ctx.Cookie("lang"); err != nil || value != "zh" {
+		t.Errorf("Cookie 错误: 得到 %q, %v", value, err)
+	}
+	if _, err := ctx.Cookie("missing"); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("期待 http.ErrNoCookie, 得到 %v", err)
+	}
+
+	cookie := &http.Cookie{Name: "token", Value: "abc"}
+	ctx.SetCookie(cookie)
+	ctx.ClearCookie("lang")
+	if cookie.Secure || cookie.Path != "" {
+		t.Error("SetCookie 不应修改传入的 Cookie")
+	}
+	// 需要被 JavaScript 读取的 CSRF 令牌
+	ctx.SetScriptCookie(&http.Cookie{Name: "csrf", Value: "xyz", HttpOnly: true})
+	scoped := &http.Cookie{Name: "pref", Value: "1", Path: "/admin", Domain: "example.com"}
+	ctx.ExpireCookie(scoped)
+	if scoped.Value != "1" || scoped.MaxAge != 0 {
+		t.Error("ExpireCookie 不应修改传入的 Cookie")
+	}
+
+	cookies := resp.Result().Cookies()
+	if len(cookies) != 4 {
+		t.Fatalf("Cookie 数量错误: 得到 %v, 期待 %v", len(cookies), 4)
+	}
+	token := cookies[0]
+	if !token.HttpOnly || !token.Secure || token.SameSite != http.SameSiteLaxMode || token.Path != "/" {
+		t.Errorf("默认值错误: HttpOnly=%v Secure=%v SameSite=%v Path=%q", token.HttpOnly, token.Secure, token.SameSite, token.Path)
+	}
+	if cleared := cookies[1]; cleared.Name != "lang" || cleared.MaxAge >= 0 || cleared.Path != "/" {
+		t.Errorf("ClearCookie 错误: %v", cleared)
+	}
+	if csrf := cookies[2]; csrf.HttpOnly || !csrf.Secure {
+		t.Errorf("SetScriptCookie 不应设置 HttpOnly: HttpOnly=%v Secure=%v", csrf.HttpOnly, csrf.Secure)
+	}
+	if expired := cookies[3]; expired.Name != "pref" || expired.Value != "" || expired.MaxAge >= 0 ||
+		expired.Path != "/admin" || expired.Domain != "example.com" {
+		t.Errorf("ExpireCookie 错误: %v", expired)
+	}
+}
+
+// 测试签名 Cookie 与密钥轮换
+func TestSignedCookie(t *testing.T) {
+	sign := func(keys []string, value string) string {
+		cfg := DefaultConfig()
+		cfg.CookieKeys = keys
+		resp := httptest.NewRecorder()
+		ctx := NewContext(resp, httptest.NewRequest(http.MethodGet, "/", nil), cfg)
+		if err := ctx.SetSignedCookie(&http.Cookie{Name: "uid", Value: value}); err != nil {
+			t.Fatalf("设置签名 Cookie 失败: %v", err)
+		}
+		signed := resp.Result().Cookies()[0]
+		if !signed.HttpOnly {
+			t.Error("签名 Cookie 应设置 HttpOnly")
+		}
+		return signed.Value
+	}
+	verify := func(keys []string, name, raw string) (string, error) {
+		cfg := DefaultConfig()
+		cfg.CookieKeys = keys
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: name, Value: raw})
+		return NewContext(httptest.NewRecorder(), req, cfg).SignedCookie(name)
+	}
+
+	oldSigned := sign([]string{"old-key"}, "42; admin=1")
+	if value, err := verify([]string{"old-key"}, "uid", oldSigned); err != nil || value != "42; admin=1" {
+		t.Errorf("验证失败: 得到 %q, %v", value, err)
+	}
+	// 轮换密钥后，旧密钥签名的 Cookie 仍然有效
+	if value, err := verify([]string{"new-key", "old-key"}, "uid", oldSigned); err != nil || value != "42; admin=1" {
+		t.Errorf("轮换后验证失败: 得到 %q, %v", value, err)
+	}
+	if _, err := verify([]string{"new-key"}, "uid", oldSigned); !errors.Is(err, ErrInvalidCookieSignature) {
+		t.Errorf("移除旧密钥后应验证失败, 得到 %v", err)
+	}
+	// 值被挪用到其他 Cookie 或被篡改
+	if _, err := verify([]string{"old-key"}, "other", oldSigned); !errors.Is(err, ErrInvalidCookieSignature) {
+		t.Errorf("挪用的 Cookie 应验证失败, 得到 %v", err)
+	}
+	tampered := "NDM" + oldSigned[strings.Index(oldSigned, "."):]
+	if _, err := verify([]string{"old-key"}, "uid", tampered); !errors.Is(err, ErrInvalidCookieSignature) {
+		t.Errorf("篡改的 Cookie 应验证失败, 得到 %v", err)
+	}
+	if _, err := verify(nil, "uid", oldSigned); !errors.Is(err, ErrNoCookieKeys) {
+		t.Errorf("未配置密钥时应返回 ErrNoCookieKeys, 得到 %v", err)
+	}
+}
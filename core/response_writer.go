@@ -0,0 +1,144 @@
+package core
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter 包装 http.ResponseWriter，记录状态码、已写入的字节数以及响应头是否已经发送
+// 同时保留 http.Flusher、http.Hijacker、io.ReaderFrom 与 http.Pusher，并实现 Unwrap 供 http.ResponseController 使用
+type ResponseWriter struct {
+	http.ResponseWriter
+	status      int   // 已发送的状态码
+	size        int64 // 已写入的响应体字节数
+	wroteHeader bool  // 响应头是否已经发送
+}
+
+// NewResponseWriter 创建一个新的 ResponseWriter
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	rw := &ResponseWriter{}
+	rw.Reset(w)
+	return rw
+}
+
+// Reset 重置记录的状态并包装新的 http.ResponseWriter，便于对象复用
+func (rw *ResponseWriter) Reset(w http.ResponseWriter) {
+	rw.ResponseWriter = w
+	rw.status = 0
+	rw.size = 0
+	rw.wroteHeader = false
+}
+
+// WriteHeader 发送响应头并记录状态码，重复调用时忽略
+// 1xx 信息响应（101 除外）可以多次发送，不影响最终的状态码
+func (rw *ResponseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	rw.status = code
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write 写入响应体并累计字节数，未发送响应头时先发送 200
+func (rw *ResponseWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	rw.size += int64(n)
+	return n, err
+}
+
+// WriteString 实现 io.StringWriter，底层支持时避免字符串到字节切片的转换
+func (rw *ResponseWriter) WriteString(s string) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := io.WriteString(rw.ResponseWriter, s)
+	rw.size += int64(n)
+	return n, err
+}
+
+// ReadFrom 实现 io.ReaderFrom，底层支持时可以使用 sendfile 等零拷贝方式发送文件
+func (rw *ResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{rw.ResponseWriter}, r)
+	}
+	rw.size += n
+	return n, err
+}
+
+// Flush 实现 http.Flusher，底层不支持刷新时忽略
+func (rw *ResponseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack 实现 http.Hijacker，用于 WebSocket 等协议升级
+func (rw *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil && !rw.wroteHeader {
+		rw.status = http.StatusSwitchingProtocols
+		rw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// Push 实现 http.Pusher，底层不支持 HTTP/2 服务器推送时返回 http.ErrNotSupported
+func (rw *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	w := rw.ResponseWriter
+	for {
+		switch v := w.(type) {
+		case http.Pusher:
+			return v.Push(target, opts)
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return http.ErrNotSupported
+		}
+	}
+}
+
+// Unwrap 返回被包装的 http.ResponseWriter
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Status 返回已发送的状态码；尚未发送时返回 200，即处理函数不写入任何内容时客户端收到的状态码
+func (rw *ResponseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// Size 返回已写入的响应体字节数
+func (rw *ResponseWriter) Size() int64 {
+	return rw.size
+}
+
+// Written 返回响应头是否已经发送
+func (rw *ResponseWriter) Written() bool {
+	return rw.wroteHeader
+}
+
+// writerOnly 隐藏 io.ReaderFrom，防止 io.Copy 递归调用 ReadFrom
+type writerOnly struct {
+	io.Writer
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试状态码与响应大小的记录
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+
+	if rw.Written() || rw.Status() != http.StatusOK || rw.Size() != 0 {
+		t.Errorf("初始状态错误: Written=%v Status=%v Size=%v", rw.Written(), rw.Status(), rw.Size())
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusInternalServerError) // 重复调用被忽略
+	_, _ = rw.Write([]byte("hello"))
+	_, _ = io.WriteString(rw, ", ")
+	_, _ = io.Copy(rw, strings.NewReader("KangGo"))
+
+	if rw.Status() != http.StatusCreated || rec.Code != http.StatusCreated {
+		t.Errorf("状态码错误: 得到 %v/%v, 期待 %v", rw.Status(), rec.Code, http.StatusCreated)
+	}
+	if rw.Size() != int64(len("hello, KangGo")) || rec.Body.String() != "hello, KangGo" {
+		t.Errorf("响应大小错误: 得到 %v, 内容 %q", rw.Size(), rec.Body.String())
+	}
+	if !rw.Written() {
+		t.Error("响应头应已发送")
+	}
+}
+
+// 测试 Flush、Push 与 Unwrap 的保留
+func TestResponseWriterInterfaces(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var w http.ResponseWriter = NewResponseWriter(rec)
+
+	w.(http.Flusher).Flush()
+	if !rec.Flushed || w.(*ResponseWriter).Status() != http.StatusOK {
+		t.Error("Flush 未传递到底层 ResponseWriter")
+	}
+	if err := w.(http.Pusher).Push("/app.js", nil); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("期待 http.ErrNotSupported, 得到 %v", err)
+	}
+	if _, _, err := http.NewResponseController(w).Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("期待 http.ErrNotSupported, 得到 %v", err)
+	}
+	if w.(*ResponseWriter).Unwrap() != rec {
+		t.Error("Unwrap 应返回被包装的 ResponseWriter")
+	}
+}
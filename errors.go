@@ -0,0 +1,69 @@
+package kanggo
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorHandler 处理函数返回错误时调用的错误处理函数
+type ErrorHandler func(ctx *Context, err error)
+
+// Error 是携带 HTTP 状态码的错误，处理函数返回它时默认错误处理函数会使用对应的状态码
+type Error struct {
+	Code    int    // HTTP 状态码
+	Message string // 返回给客户端的错误信息
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError 创建一个携带状态码的错误，未提供 message 时使用状态码对应的标准描述
+func NewError(code int, message ...string) *Error {
+	err := &Error{Code: code, Message: http.StatusText(code)}
+	if len(message) > 0 {
+		err.Message = message[0]
+	}
+	return err
+}
+
+// 常用的错误
+var (
+	ErrBadRequest         = NewError(http.StatusBadRequest)          // 400
+	ErrForbidden          = NewError(http.StatusForbidden)           // 403
+	ErrNotFound           = NewError(http.StatusNotFound)            // 404
+	ErrRequestTimeout     = NewError(http.StatusRequestTimeout)      // 408
+	ErrInternalServer     = NewError(http.StatusInternalServerError) // 500
+	ErrServiceUnavailable = NewError(http.StatusServiceUnavailable)  // 503
+	ErrGatewayTimeout     = NewError(http.StatusGatewayTimeout)      // 504
+)
+
+// DefaultErrorHandler 默认的错误处理函数
+// *Error 使用其状态码与信息，其他错误返回 500 与错误信息；
+// *TemplateError 在开发模式下显示模板错误页面，否则只返回 500 的标准描述，避免泄露模板源码
+func DefaultErrorHandler(ctx *Context, err error) {
+	code := http.StatusInternalServerError
+	var e *Error
+	if errors.As(err, &e) {
+		code = e.Code
+	}
+	var te *TemplateError
+	if errors.As(err, &te) {
+		if te.Debug && te.WriteDebugPage(ctx.Writer) == nil {
+			return
+		}
+		http.Error(ctx.Writer, http.StatusText(code), code)
+		return
+	}
+	http.Error(ctx.Writer, err.Error(), code)
+}
+
+// Error 将 err 交给配置的错误处理函数生成响应，供中间件等处理函数之外的代码使用
+func (c *Context) Error(err error) {
+	if c.errorHandler == nil {
+		DefaultErrorHandler(c, err)
+		return
+	}
+	c.errorHandler(c, err)
+}
@@ -1,3 +1,11 @@
 module github.com/7836246/kanggo
 
-go 1.23.1
\ No newline at end of file
+go 1.23.1
+
+require (
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	google.golang.org/protobuf v1.36.9
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
@@ -20,48 +20,48 @@ func (r *Router) NewGroup(prefix string) *Group {
 }
 
 // GET 方法为路由组注册一个 GET 请求处理函数
-func (g *Group) GET(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodGet, g.Prefix+pattern, handler)
+func (g *Group) GET(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodGet, g.Prefix+pattern, handler)
 }
 
 // POST 方法为路由组注册一个 POST 请求处理函数
-func (g *Group) POST(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodPost, g.Prefix+pattern, handler)
+func (g *Group) POST(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodPost, g.Prefix+pattern, handler)
 }
 
 // PUT 方法为路由组注册一个 PUT 请求处理函数
-func (g *Group) PUT(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodPut, g.Prefix+pattern, handler)
+func (g *Group) PUT(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodPut, g.Prefix+pattern, handler)
 }
 
 // DELETE 方法为路由组注册一个 DELETE 请求处理函数
-func (g *Group) DELETE(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodDelete, g.Prefix+pattern, handler)
+func (g *Group) DELETE(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodDelete, g.Prefix+pattern, handler)
 }
 
 // PATCH 方法为路由组注册一个 PATCH 请求处理函数
-func (g *Group) PATCH(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodPatch, g.Prefix+pattern, handler)
+func (g *Group) PATCH(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodPatch, g.Prefix+pattern, handler)
 }
 
 // OPTIONS 方法为路由组注册一个 OPTIONS 请求处理函数
-func (g *Group) OPTIONS(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodOptions, g.Prefix+pattern, handler)
+func (g *Group) OPTIONS(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodOptions, g.Prefix+pattern, handler)
 }
 
 // HEAD 方法为路由组注册一个 HEAD 请求处理函数
-func (g *Group) HEAD(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodHead, g.Prefix+pattern, handler)
+func (g *Group) HEAD(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodHead, g.Prefix+pattern, handler)
 }
 
 // TRACE 方法为路由组注册一个 TRACE 请求处理函数
-func (g *Group) TRACE(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodTrace, g.Prefix+pattern, handler)
+func (g *Group) TRACE(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodTrace, g.Prefix+pattern, handler)
 }
 
 // CONNECT 方法为路由组注册一个 CONNECT 请求处理函数
-func (g *Group) CONNECT(pattern string, handler HandlerFunc) {
-	g.Router.Handle(constants.MethodConnect, g.Prefix+pattern, handler)
+func (g *Group) CONNECT(pattern string, handler HandlerFunc) *Route {
+	return g.Router.Handle(constants.MethodConnect, g.Prefix+pattern, handler)
 }
 
 // Add 方法允许您指定一个方法作为值来注册一个路由
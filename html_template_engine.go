@@ -1,50 +1,295 @@
 package kanggo
 
 import (
+	"errors"
+	"fmt"
 	"github.com/7836246/kanggo/constants"
 	"html/template"
+	"io"
+	"io/fs"
 	"net/http"
-	"path/filepath"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 )
 
+// yieldTemplate 是布局中 {{yield}} 指向的模板名，在每个页面的模板集合中代表该页面本身
+const yieldTemplate = "kanggo/yield"
+
+// yieldPattern 匹配布局中的 {{yield}}，支持 {{- yield -}} 形式的空白裁剪
+var yieldPattern = regexp.MustCompile(`\{\{(-?)\s*yield\s*(-?)\}\}`)
+
 // HTMLTemplateEngine 使用 Go 标准库 html/template 的模板引擎
+// 模板按相对于模板根目录的路径命名（例如 "users/show.html"），子目录会被递归加载。
+// 共享目录（默认为 layouts 与 partials）中的模板对所有页面可见，其余每个页面拥有独立的模板集合，
+// 因此不同页面中的 {{define "content"}} 不会互相覆盖。布局通过 {{yield}} 输出页面内容。
 type HTMLTemplateEngine struct {
-	templates *template.Template
-	lock      sync.RWMutex
-	dir       string
-	pattern   string
+	pages       map[string]*template.Template // 每个页面独立的模板集合
+	shared      *template.Template            // 只包含共享模板的集合
+	sources     map[string]string             // 模板源码，用于在开发模式的错误页面中显示
+	helpers     templateHelpers
+	lock        sync.RWMutex
+	fsys        fs.FS
+	pattern     string
+	layout      string
+	sharedDirs  []string
+	reload      bool
+	fingerprint string
 }
 
-// NewHTMLTemplateEngine 创建一个新的 HTMLTemplateEngine 实例
+// NewHTMLTemplateEngine 创建一个从磁盘目录 dir 加载模板的 HTMLTemplateEngine 实例
+// pattern 用于匹配文件名，例如 "*.html"
 func NewHTMLTemplateEngine(dir, pattern string) *HTMLTemplateEngine {
-	return &HTMLTemplateEngine{dir: dir, pattern: pattern}
+	return NewHTMLTemplateEngineFS(os.DirFS(dir), pattern)
+}
+
+// NewHTMLTemplateEngineFS 创建一个从 fsys 加载模板的 HTMLTemplateEngine 实例，可直接使用 embed.FS
+// 嵌入的文件没有修改时间，Reload 对其不起作用
+func NewHTMLTemplateEngineFS(fsys fs.FS, pattern string) *HTMLTemplateEngine {
+	return &HTMLTemplateEngine{fsys: fsys, pattern: pattern, sharedDirs: []string{"layouts", "partials"}}
+}
+
+// Layout 设置默认布局，例如 "layouts/main.html"，Render 会将页面嵌入该布局的 {{yield}} 处
+// 默认布局即使不在共享目录中也会对所有页面可见
+func (e *HTMLTemplateEngine) Layout(name string) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.layout = name
+	return e
+}
+
+// Shared 设置共享目录，这些目录中的模板（布局与局部模板）对所有页面可见，默认为 "layouts" 与 "partials"
+func (e *HTMLTemplateEngine) Shared(dirs ...string) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.sharedDirs = dirs
+	return e
+}
+
+// Reload 设置是否在模板文件变化后自动重新加载，适用于开发环境
+// 开启后每次渲染都会检查文件的大小与修改时间，渲染失败时 DefaultErrorHandler 会显示包含源码与数据的错误页面，
+// 生产环境应保持关闭
+func (e *HTMLTemplateEngine) Reload(enabled bool) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.reload = enabled
+	return e
+}
+
+// Funcs 添加自定义模板函数，同名时覆盖内置函数，必须在 Load 之前调用
+func (e *HTMLTemplateEngine) Funcs(funcs FuncMap) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.addFuncs(funcs)
+	return e
+}
+
+// Assets 设置 asset 函数使用的资源目录与地址前缀，生成的地址带有文件内容的指纹
+// 例如 Assets("/static", os.DirFS("public")) 后 {{asset "app.css"}} 输出 /static/app.css?v=1a2b3c4d
+func (e *HTMLTemplateEngine) Assets(prefix string, fsys fs.FS) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.assetPrefix = prefix
+	e.helpers.assets = fsys
+	return e
 }
 
-// Load 加载模板文件
+// Router 设置 url 函数查找命名路由时使用的路由器
+func (e *HTMLTemplateEngine) Router(router *Router) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.router = router
+	return e
+}
+
+// bindRouter 在未通过 Router 设置路由器时使用应用的路由器
+func (e *HTMLTemplateEngine) bindRouter(router *Router) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.helpers.router == nil {
+		e.helpers.router = router
+	}
+}
+
+// Translate 设置 t 函数使用的翻译函数
+func (e *HTMLTemplateEngine) Translate(translator Translator) *HTMLTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.translator = translator
+	return e
+}
+
+// Load 递归加载模板文件，并为每个页面构建独立的模板集合
 func (e *HTMLTemplateEngine) Load() error {
 	e.lock.Lock()
 	defer e.lock.Unlock()
+	return e.load()
+}
 
-	tmpl, err := template.ParseGlob(filepath.Join(e.dir, e.pattern))
+// load 在持有写锁时加载模板
+func (e *HTMLTemplateEngine) load() error {
+	files, fingerprint, err := e.scan()
 	if err != nil {
 		return err
 	}
-	e.templates = tmpl
+	if len(files) == 0 {
+		return fmt.Errorf("没有匹配 %s 的模板文件", e.pattern)
+	}
+
+	// 先解析共享模板，页面集合在其副本上解析，页面中的 define 可以覆盖布局中 block 的默认内容
+	shared := template.New("").Funcs(e.helpers.funcMap(true))
+	var pages []string
+	sources := make(map[string]string, len(files))
+	contents := make(map[string]string, len(files))
+	for _, name := range files {
+		data, err := fs.ReadFile(e.fsys, name)
+		if err != nil {
+			return err
+		}
+		sources[name] = string(data)
+		contents[name] = yieldPattern.ReplaceAllStringFunc(string(data), rewriteYield)
+		if !e.isShared(name) {
+			pages = append(pages, name)
+			continue
+		}
+		if _, err := shared.New(name).Parse(contents[name]); err != nil {
+			return newTemplateError(name, nil, err, sources, e.reload)
+		}
+	}
+
+	sets := make(map[string]*template.Template, len(pages))
+	for _, name := range pages {
+		set, err := shared.Clone()
+		if err != nil {
+			return err
+		}
+		if _, err := set.New(name).Parse(contents[name]); err != nil {
+			return newTemplateError(name, nil, err, sources, e.reload)
+		}
+		if _, err := set.New(yieldTemplate).Parse(`{{template ` + strconv.Quote(name) + ` .}}`); err != nil {
+			return err
+		}
+		sets[name] = set
+	}
+
+	e.shared = shared
+	e.pages = sets
+	e.sources = sources
+	e.fingerprint = fingerprint
 	return nil
 }
 
-// Render 渲染模板
+// scan 按文件名模式递归查找模板文件，同时根据文件大小与修改时间生成指纹用于检测变化
+func (e *HTMLTemplateEngine) scan() ([]string, string, error) {
+	var files []string
+	var fingerprint strings.Builder
+	err := fs.WalkDir(e.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if matched, err := path.Match(e.pattern, d.Name()); err != nil || !matched {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, name)
+		fmt.Fprintf(&fingerprint, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return files, fingerprint.String(), err
+}
+
+// isShared 判断模板是否对所有页面可见
+func (e *HTMLTemplateEngine) isShared(name string) bool {
+	if name == e.layout {
+		return true
+	}
+	dir, _, nested := strings.Cut(name, "/")
+	return nested && slices.Contains(e.sharedDirs, dir)
+}
+
+// rewriteYield 将布局中的 {{yield}} 改写为对当前页面模板的调用，保留空白裁剪标记
+func rewriteYield(match string) string {
+	groups := yieldPattern.FindStringSubmatch(match)
+	action := `template "` + yieldTemplate + `" $`
+	if groups[1] != "" {
+		action = "- " + action
+	}
+	if groups[2] != "" {
+		action += " -"
+	}
+	return "{{" + action + "}}"
+}
+
+// reloadIfChanged 在开启自动重新加载且模板文件发生变化时重新加载
+func (e *HTMLTemplateEngine) reloadIfChanged() error {
+	e.lock.RLock()
+	reload, previous := e.reload, e.fingerprint
+	e.lock.RUnlock()
+	if !reload {
+		return nil
+	}
+
+	_, fingerprint, err := e.scan()
+	if err != nil || fingerprint == previous {
+		return err
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.load()
+}
+
+// Render 渲染模板，设置了默认布局时将页面嵌入布局中
 func (e *HTMLTemplateEngine) Render(w http.ResponseWriter, name string, data interface{}) error {
+	e.lock.RLock()
+	layout := e.layout
+	e.lock.RUnlock()
+	return e.RenderLayout(w, name, layout, data)
+}
+
+// RenderLayout 使用指定的布局渲染页面，layout 为空字符串时不使用布局
+// 布局必须位于共享目录中或是默认布局。模板先渲染到缓冲区，成功后才写入响应，
+// 失败时返回 *TemplateError 且不写入任何内容，模板不存在时错误满足 errors.Is(err, ErrTemplateNotFound)
+func (e *HTMLTemplateEngine) RenderLayout(w http.ResponseWriter, name, layout string, data interface{}) error {
+	if err := e.reloadIfChanged(); err != nil {
+		var te *TemplateError
+		if errors.As(err, &te) {
+			te.Data = data
+		}
+		return err
+	}
+
 	e.lock.RLock()
 	defer e.lock.RUnlock()
 
-	tmpl := e.templates.Lookup(name)
+	set, ok := e.pages[name]
+	if !ok {
+		// 共享模板（例如局部模板）也可以直接渲染
+		set = e.shared
+	}
+	target := name
+	if layout != "" && ok {
+		target = layout
+	}
+
+	var tmpl *template.Template
+	if set != nil {
+		tmpl = set.Lookup(target)
+	}
 	if tmpl == nil {
-		http.Error(w, "模板未找到", http.StatusInternalServerError)
-		return nil
+		return newTemplateError(target, data, ErrTemplateNotFound, nil, e.reload)
 	}
 
-	w.Header().Set("Content-Type", constants.MIMETextHTML)
-	return tmpl.Execute(w, data)
+	return executeTemplate(w, constants.MIMETextHTMLCharsetUTF8, func(buf io.Writer) error {
+		if err := tmpl.Execute(buf, data); err != nil {
+			return newTemplateError(name, data, err, e.sources, e.reload)
+		}
+		return nil
+	})
 }
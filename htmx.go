@@ -0,0 +1,135 @@
+package kanggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// IsHTMX 判断请求是否由 htmx 发起（请求头 HX-Request: true）
+func (c *Context) IsHTMX() bool {
+	return c.Request.Header.Get(constants.HeaderHXRequest) == "true"
+}
+
+// IsHTMXBoosted 判断请求是否由 hx-boost 增强的链接或表单发起，这类请求期待完整的页面
+func (c *Context) IsHTMXBoosted() bool {
+	return c.Request.Header.Get(constants.HeaderHXBoosted) == "true"
+}
+
+// RenderPartial 渲染名为 name 的模板：htmx 发起的请求只返回模板本身的片段，不使用布局；
+// 普通请求与 hx-boost 请求使用默认布局返回完整页面。响应头 Vary 中会加入 HX-Request，避免缓存混用两种响应
+func (c *Context) RenderPartial(name string, data interface{}) error {
+	c.Writer.Header().Add(constants.HeaderVary, constants.HeaderHXRequest)
+	if c.IsHTMX() && !c.IsHTMXBoosted() {
+		if _, ok := c.TemplateEngine.(LayoutRenderer); ok {
+			return c.Render(http.StatusOK, name, data, "")
+		}
+	}
+	return c.Render(http.StatusOK, name, data)
+}
+
+// RenderOOB 在一个响应中依次渲染多个模板（均不使用布局）：第一个模板替换请求的目标元素，
+// 其余模板的根元素需要带有 hx-swap-oob 属性，由 htmx 按 id 带外替换页面中的其他位置。
+// 所有模板都渲染成功后才写入响应，任意一个失败时返回其错误
+func (c *Context) RenderOOB(data interface{}, names ...string) error {
+	if c.TemplateEngine == nil {
+		return ErrNoTemplateEngine
+	}
+
+	data = c.viewData(data)
+	fragment := &fragmentWriter{header: make(http.Header)}
+	for _, name := range names {
+		var err error
+		if renderer, ok := c.TemplateEngine.(LayoutRenderer); ok {
+			err = renderer.RenderLayout(fragment, name, "", data)
+		} else {
+			err = c.TemplateEngine.Render(fragment, name, data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	contentType := fragment.header.Get(constants.HeaderContentType)
+	if contentType == "" {
+		contentType = constants.MIMETextHTMLCharsetUTF8
+	}
+	return c.send(http.StatusOK, contentType, fragment.body.Bytes())
+}
+
+// HXRedirect 让 htmx 在客户端整页跳转到 location，与 Redirect 一样只允许安全的地址
+func (c *Context) HXRedirect(location string) error {
+	if !c.IsSafeRedirect(location) {
+		return ErrUnsafeRedirect
+	}
+	c.Writer.Header().Set(constants.HeaderHXRedirect, location)
+	return nil
+}
+
+// HXRefresh 让 htmx 在客户端刷新整个页面
+func (c *Context) HXRefresh() {
+	c.Writer.Header().Set(constants.HeaderHXRefresh, "true")
+}
+
+// HXPushURL 将 url 写入浏览器历史记录，传入 "false" 可阻止 hx-push-url 写入
+func (c *Context) HXPushURL(url string) {
+	c.Writer.Header().Set(constants.HeaderHXPushURL, url)
+}
+
+// HXReswap 覆盖本次响应的交换方式，例如 "outerHTML"
+func (c *Context) HXReswap(swap string) {
+	c.Writer.Header().Set(constants.HeaderHXReswap, swap)
+}
+
+// HXRetarget 将本次响应的更新目标改为 selector 选中的元素
+func (c *Context) HXRetarget(selector string) {
+	c.Writer.Header().Set(constants.HeaderHXRetarget, selector)
+}
+
+// HXTrigger 在客户端触发名为 event 的事件，detail 会作为事件的 detail 传给监听函数
+// 多次调用会合并到同一个 HX-Trigger 响应头中，响应头使用 htmx 支持的 JSON 对象格式
+func (c *Context) HXTrigger(event string, detail ...interface{}) error {
+	header := c.Writer.Header()
+	events := make(map[string]json.RawMessage)
+	if existing := header.Get(constants.HeaderHXTrigger); existing != "" {
+		if strings.HasPrefix(existing, "{") {
+			if err := json.Unmarshal([]byte(existing), &events); err != nil {
+				return err
+			}
+		} else {
+			// 逗号分隔的事件名称列表
+			for _, name := range strings.Split(existing, ",") {
+				events[strings.TrimSpace(name)] = json.RawMessage("null")
+			}
+		}
+	}
+
+	value := json.RawMessage("null")
+	if len(detail) > 0 {
+		encoded, err := json.Marshal(detail[0])
+		if err != nil {
+			return err
+		}
+		value = encoded
+	}
+	events[event] = value
+
+	encoded, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	header.Set(constants.HeaderHXTrigger, string(encoded))
+	return nil
+}
+
+// fragmentWriter 收集多个模板片段的输出，供 RenderOOB 合并为一个响应
+type fragmentWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *fragmentWriter) Header() http.Header         { return w.header }
+func (w *fragmentWriter) Write(b []byte) (int, error) { return w.body.Write(b) }
+func (w *fragmentWriter) WriteHeader(int)             {}
@@ -0,0 +1,123 @@
+package kanggo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newHTMXApp 创建带有布局与片段模板的应用
+func newHTMXApp(t *testing.T) *KangGo {
+	t.Helper()
+	dir := writeTemplates(t, map[string]string{
+		"layouts/main.html": `<html>{{yield}}</html>`,
+		"todos.html":        `<ul id="todos"><li>{{.item}}</li></ul>`,
+		"counter.html":      `<span id="count" hx-swap-oob="true">{{.count}}</span>`,
+	})
+	app := New(Config{Views: NewHTMLTemplateEngine(dir, "*.html").Layout("layouts/main.html")})
+	if err := app.LoadViews(); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	return app
+}
+
+// 测试 htmx 请求跳过布局，普通请求与 hx-boost 请求返回完整页面
+func TestRenderPartial(t *testing.T) {
+	app := newHTMXApp(t)
+	app.GET("/todos", func(ctx *Context) error {
+		ctx.Set("item", "写代码")
+		return ctx.RenderPartial("todos.html", nil)
+	})
+
+	cases := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"普通请求", nil, `<html><ul id="todos"><li>写代码</li></ul></html>`},
+		{"htmx 请求", map[string]string{"HX-Request": "true"}, `<ul id="todos"><li>写代码</li></ul>`},
+		{"hx-boost 请求", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, `<html><ul id="todos"><li>写代码</li></ul></html>`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		for key, value := range tc.headers {
+			req.Header.Set(key, value)
+		}
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, req)
+		if resp.Body.String() != tc.expected {
+			t.Errorf("%s: 渲染结果不正确: 得到 %s，期望 %s", tc.name, resp.Body.String(), tc.expected)
+		}
+		if vary := resp.Header().Get("Vary"); vary != "HX-Request" {
+			t.Errorf("%s: Vary 头错误: 得到 %v", tc.name, vary)
+		}
+	}
+}
+
+// 测试在一个响应中渲染主片段与带外替换片段
+func TestRenderOOB(t *testing.T) {
+	app := newHTMXApp(t)
+	app.POST("/todos", func(ctx *Context) error {
+		return ctx.RenderOOB(map[string]interface{}{"item": "测试", "count": 3}, "todos.html", "counter.html")
+	})
+	app.POST("/broken", func(ctx *Context) error {
+		return ctx.RenderOOB(nil, "todos.html", "missing.html")
+	})
+
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/todos", nil))
+	expected := `<ul id="todos"><li>测试</li></ul><span id="count" hx-swap-oob="true">3</span>`
+	if resp.Body.String() != expected {
+		t.Errorf("渲染结果不正确: 得到 %s，期望 %s", resp.Body.String(), expected)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type 错误: 得到 %v", ct)
+	}
+
+	resp = httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/broken", nil))
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusInternalServerError)
+	}
+}
+
+// 测试 HTMX 响应头辅助方法
+func TestHTMXHeaders(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), Config{})
+
+	if err := ctx.HXTrigger("saved"); err != nil {
+		t.Fatalf("设置 HX-Trigger 失败: %v", err)
+	}
+	if err := ctx.HXTrigger("notify", map[string]string{"level": "info"}); err != nil {
+		t.Fatalf("设置 HX-Trigger 失败: %v", err)
+	}
+	expected := `{"notify":{"level":"info"},"saved":null}`
+	if trigger := ctx.Writer.Header().Get("HX-Trigger"); trigger != expected {
+		t.Errorf("HX-Trigger 错误: 得到 %s, 期待 %s", trigger, expected)
+	}
+
+	if err := ctx.HXRedirect("/login"); err != nil {
+		t.Errorf("安全的地址不应返回错误: %v", err)
+	}
+	if ctx.Writer.Header().Get("HX-Redirect") != "/login" {
+		t.Errorf("HX-Redirect 错误: 得到 %s", ctx.Writer.Header().Get("HX-Redirect"))
+	}
+	if err := ctx.HXRedirect("https://evil.example/"); err != ErrUnsafeRedirect {
+		t.Errorf("期待 ErrUnsafeRedirect, 得到 %v", err)
+	}
+
+	ctx.HXRefresh()
+	ctx.HXPushURL("/todos?page=2")
+	ctx.HXReswap("outerHTML")
+	ctx.HXRetarget("#list")
+	for header, want := range map[string]string{
+		"HX-Refresh":  "true",
+		"HX-Push-Url": "/todos?page=2",
+		"HX-Reswap":   "outerHTML",
+		"HX-Retarget": "#list",
+	} {
+		if got := ctx.Writer.Header().Get(header); got != want {
+			t.Errorf("%s 错误: 得到 %s, 期待 %s", header, got, want)
+		}
+	}
+}
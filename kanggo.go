@@ -9,9 +9,8 @@ import (
 
 // KangGo 核心结构
 type KangGo struct {
-	Router     *Router
-	Config     Config
-	middleware []core.MiddlewareFunc // 用于存储中间件函数的切片
+	Router *Router
+	Config Config
 }
 
 // Default 创建一个带有默认设置的 KangGo 实例
@@ -25,9 +24,13 @@ func Default() *KangGo {
 func New(cfg Config) *KangGo {
 	// 创建 KangGo 实例
 	k := &KangGo{
-		Router:     NewRouter(cfg), // 将配置传递给 NewRouter
-		Config:     cfg,
-		middleware: []core.MiddlewareFunc{}, // 初始化中间件切片
+		Router: NewRouter(cfg), // 将配置传递给 NewRouter
+		Config: cfg,
+	}
+
+	// 模板引擎中的 url 函数使用应用的路由器生成地址
+	if binder, ok := cfg.Views.(routerBinder); ok {
+		binder.bindRouter(k.Router)
 	}
 
 	// 根据配置决定是否打印横幅
@@ -35,70 +38,57 @@ func New(cfg Config) *KangGo {
 		PrintWelcomeBanner()
 	}
 
-	// 注册应用级别的中间件到 Router
-	k.Router.Use(func(next http.HandlerFunc) http.HandlerFunc { // 确保符合 MiddlewareFunc 类型
-		return k.applyMiddleware(next)
-	})
-
 	return k
 }
 
-// Use 注册一个中间件
+// Use 注册一个中间件，中间件链在注册时构建，处理请求时不再重复包装
 func (k *KangGo) Use(middleware core.MiddlewareFunc) {
-	k.middleware = append(k.middleware, middleware)
-}
-
-// applyMiddleware 应用中间件链到最终处理程序
-func (k *KangGo) applyMiddleware(finalHandler http.HandlerFunc) http.HandlerFunc {
-	for i := len(k.middleware) - 1; i >= 0; i-- {
-		finalHandler = k.middleware[i](finalHandler) // 使用中间件包装处理程序
-	}
-	return finalHandler
+	k.Router.Use(middleware)
 }
 
 // GET 注册一个 GET 请求路由
-func (k *KangGo) GET(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodGet, pattern, handler)
+func (k *KangGo) GET(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodGet, pattern, handler)
 }
 
 // POST 注册一个 POST 请求路由
-func (k *KangGo) POST(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodPost, pattern, handler)
+func (k *KangGo) POST(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodPost, pattern, handler)
 }
 
 // PUT 注册一个 PUT 请求路由
-func (k *KangGo) PUT(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodPut, pattern, handler)
+func (k *KangGo) PUT(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodPut, pattern, handler)
 }
 
 // DELETE 注册一个 DELETE 请求路由
-func (k *KangGo) DELETE(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodDelete, pattern, handler)
+func (k *KangGo) DELETE(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodDelete, pattern, handler)
 }
 
 // PATCH 注册一个 PATCH 请求路由
-func (k *KangGo) PATCH(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodPatch, pattern, handler)
+func (k *KangGo) PATCH(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodPatch, pattern, handler)
 }
 
 // OPTIONS 注册一个 OPTIONS 请求路由
-func (k *KangGo) OPTIONS(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodOptions, pattern, handler)
+func (k *KangGo) OPTIONS(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodOptions, pattern, handler)
 }
 
 // HEAD 注册一个 HEAD 请求路由
-func (k *KangGo) HEAD(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodHead, pattern, handler)
+func (k *KangGo) HEAD(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodHead, pattern, handler)
 }
 
 // TRACE 注册一个 TRACE 请求路由
-func (k *KangGo) TRACE(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodTrace, pattern, handler)
+func (k *KangGo) TRACE(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodTrace, pattern, handler)
 }
 
 // CONNECT 注册一个 CONNECT 请求路由
-func (k *KangGo) CONNECT(pattern string, handler HandlerFunc) {
-	k.Router.Handle(constants.MethodConnect, pattern, handler)
+func (k *KangGo) CONNECT(pattern string, handler HandlerFunc) *Route {
+	return k.Router.Handle(constants.MethodConnect, pattern, handler)
 }
 
 // Add 方法允许您指定一个方法作为值来注册一个路由
@@ -132,6 +122,9 @@ func (k *KangGo) All(path string, handlers ...HandlerFunc) *Router {
 
 // Run 启动 HTTP 服务器
 func (k *KangGo) Run(addr string) error {
+	if err := k.LoadViews(); err != nil {
+		return fmt.Errorf("加载模板失败: %w", err)
+	}
 	// 根据配置决定是否打印路由信息
 	if k.Config.PrintRoutes {
 		k.Router.PrintRoutes() // 打印所有注册的路由信息
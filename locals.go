@@ -0,0 +1,122 @@
+package kanggo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// localsKey 用于在 context.Context 中找到 Context 的局部变量表
+type localsKey struct{}
+
+// localStore 保存请求范围内的局部变量
+// 每个请求单独分配，Context 回收后仍被下游持有的 context.Context 不会读到其他请求的数据
+type localStore struct {
+	values map[string]interface{}
+}
+
+// localsContext 将局部变量暴露给 context.Context，使 ctx.Request.Context().Value(key) 能读到 Set 的值
+type localsContext struct {
+	context.Context
+	store *localStore
+}
+
+// Value 先查找局部变量，再查找父级 context.Context
+func (c *localsContext) Value(key interface{}) interface{} {
+	switch k := key.(type) {
+	case localsKey:
+		return c.store
+	case string:
+		if value, ok := c.store.values[k]; ok {
+			return value
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// Set 保存一个请求范围内的值，供后续的中间件、处理函数和模板使用
+// 值同时会出现在 ctx.Request.Context() 中，下游库可以通过 Value(key) 读取
+// Set 与 Get 不是并发安全的，不应在处理函数启动的 goroutine 中调用 Set
+func (c *Context) Set(key string, value interface{}) {
+	if c.locals == nil {
+		c.locals = &localStore{values: make(map[string]interface{})}
+	}
+	c.locals.values[key] = value
+	c.bindLocals()
+}
+
+// bindLocals 确保当前请求的 context.Context 能够访问局部变量表
+func (c *Context) bindLocals() {
+	if c.Request == nil {
+		return
+	}
+	parent := c.Request.Context()
+	if store, ok := parent.Value(localsKey{}).(*localStore); ok && store == c.locals {
+		return
+	}
+	c.Request = c.Request.WithContext(&localsContext{Context: parent, store: c.locals})
+}
+
+// Get 获取请求范围内的值
+// 未通过 Set 保存时，会继续查找请求的 context.Context 中以相同字符串为键的值，
+// 因此 net/http 风格的中间件通过 context.WithValue 传递的数据同样可以读到
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	if c.locals != nil {
+		if value, exists = c.locals.values[key]; exists {
+			return value, true
+		}
+	}
+	if c.Request != nil {
+		if value = c.Request.Context().Value(key); value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// MustGet 获取请求范围内的值，不存在时 panic
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic(fmt.Sprintf("kanggo: 键 %q 不存在", key))
+}
+
+// Locals 返回通过 Set 保存的所有值的副本
+func (c *Context) Locals() map[string]interface{} {
+	locals := make(map[string]interface{})
+	if c.locals != nil {
+		for key, value := range c.locals.values {
+			locals[key] = value
+		}
+	}
+	return locals
+}
+
+// Local 以类型 T 获取请求范围内的值，不存在或类型不匹配时返回 T 的零值和 false
+func Local[T any](c *Context, key string) (T, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// MustLocal 以类型 T 获取请求范围内的值，不存在或类型不匹配时 panic
+func MustLocal[T any](c *Context, key string) T {
+	value := c.MustGet(key)
+	typed, ok := value.(T)
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("kanggo: 键 %q 的类型为 %T, 而不是 %T", key, value, zero))
+	}
+	return typed
+}
+
+// ContextOf 返回与 ResponseWriter 关联的 Context，供 net/http 风格的中间件在调用下一个处理函数前写入局部变量
+// w 不是由 KangGo 传入（或中间件包装后没有实现 Unwrap）时返回 nil
+func ContextOf(w http.ResponseWriter) *Context {
+	return contextFromWriter(w)
+}
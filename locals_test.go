@@ -0,0 +1,90 @@
+package kanggo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type localsUser struct {
+	Name string
+}
+
+// 测试 Set/Get/MustGet 与类型化访问
+func TestContextLocals(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := NewContext(httptest.NewRecorder(), req, DefaultConfig())
+
+	if _, ok := ctx.Get("user"); ok {
+		t.Error("未设置的键不应存在")
+	}
+	ctx.Set("user", &localsUser{Name: "kanggo"})
+	ctx.Set("count", 3)
+
+	user, ok := Local[*localsUser](ctx, "user")
+	if !ok || user.Name != "kanggo" {
+		t.Errorf("Local 结果错误: 得到 %v, %v", user, ok)
+	}
+	if _, ok := Local[string](ctx, "count"); ok {
+		t.Error("类型不匹配时应返回 false")
+	}
+	if count := MustLocal[int](ctx, "count"); count != 3 {
+		t.Errorf("MustLocal 结果错误: 得到 %v, 期待 %v", count, 3)
+	}
+	if got := ctx.Request.Context().Value("count"); got != 3 {
+		t.Errorf("context.Context 中的值错误: 得到 %v, 期待 %v", got, 3)
+	}
+	if len(ctx.Locals()) != 2 {
+		t.Errorf("Locals 数量错误: 得到 %v, 期待 %v", len(ctx.Locals()), 2)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet 未找到键时应 panic")
+		}
+	}()
+	ctx.MustGet("missing")
+}
+
+// 测试中间件与处理函数之间传递局部变量
+func TestLocalsThroughMiddleware(t *testing.T) {
+	app := New(DefaultConfig())
+	app.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ContextOf(w).Set("requestID", "abc")
+			// 使用 context.WithValue 的中间件同样可以把数据交给处理函数
+			next(w, r.WithContext(context.WithValue(r.Context(), "tenant", "acme")))
+		}
+	})
+	app.GET("/locals", func(ctx *Context) error {
+		requestID, _ := Local[string](ctx, "requestID")
+		tenant, _ := Local[string](ctx, "tenant")
+		fromRequest, _ := ctx.Request.Context().Value("requestID").(string)
+		return ctx.SendString(requestID + "," + tenant + "," + fromRequest)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/locals", nil)
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, req)
+
+	if expected := "abc,acme,abc"; resp.Body.String() != expected {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), expected)
+	}
+
+	// Context 回收后局部变量必须被清空
+	router := NewRouter(DefaultConfig())
+	router.Handle(http.MethodGet, "/set", func(ctx *Context) error {
+		ctx.Set("stale", true)
+		return nil
+	})
+	router.Handle(http.MethodGet, "/get", func(ctx *Context) error {
+		if _, ok := ctx.Get("stale"); ok {
+			t.Error("回收的 Context 中残留了上一个请求的局部变量")
+		}
+		return nil
+	})
+	for _, path := range []string{"/set", "/get"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+}
@@ -2,25 +2,192 @@ package cors
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/7836246/kanggo"
+	"github.com/7836246/kanggo/constants"
 	"github.com/7836246/kanggo/core"
 )
 
-// New CORS 中间件
-func New() core.MiddlewareFunc {
+// Config 是 CORS 中间件的配置结构体
+type Config struct {
+	Next             func(c *kanggo.Context) bool // 可选：跳过此中间件的函数
+	AllowOrigins     []string                     // 可选：允许的来源，支持精确匹配、"https://*.example.com" 形式的子域名通配以及 "*"，默认值 ["*"]
+	AllowOriginsFunc func(origin string) bool     // 可选：自定义来源检查函数，来源不在 AllowOrigins 中时调用，返回 true 表示允许
+	AllowMethods     []string                     // 可选：预检请求中允许的方法，默认值 GET、POST、HEAD、PUT、DELETE、PATCH
+	AllowHeaders     []string                     // 可选：预检请求中允许的请求头，为空时原样返回 Access-Control-Request-Headers
+	AllowCredentials bool                         // 可选：是否允许携带 Cookie 等凭据，不能与 AllowOrigins 中的 "*" 同时使用，默认值 false
+	ExposeHeaders    []string                     // 可选：允许浏览器脚本读取的响应头，默认值为 nil
+	MaxAge           int                          // 可选：预检结果的缓存时间（秒），0 表示不发送该响应头，负数表示禁止缓存，默认值 0
+}
+
+// ConfigDefault 默认配置
+var ConfigDefault = Config{
+	Next:             nil,
+	AllowOrigins:     []string{"*"},
+	AllowOriginsFunc: nil,
+	AllowMethods: []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodHead,
+		http.MethodPut,
+		http.MethodDelete,
+		http.MethodPatch,
+	},
+	AllowHeaders:     nil,
+	AllowCredentials: false,
+	ExposeHeaders:    nil,
+	MaxAge:           0,
+}
+
+// configDefault 为未设置的配置项填充默认值
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+
+	if len(config) > 0 {
+		cfg = config[0]
+
+		// 只设置了 AllowOriginsFunc 时不再默认允许所有来源
+		if len(cfg.AllowOrigins) == 0 && cfg.AllowOriginsFunc == nil {
+			cfg.AllowOrigins = ConfigDefault.AllowOrigins
+		}
+
+		if len(cfg.AllowMethods) == 0 {
+			cfg.AllowMethods = ConfigDefault.AllowMethods
+		}
+	}
+
+	return cfg
+}
+
+// New 创建一个新的 CORS 中间件
+// 只有带 Origin、Access-Control-Request-Method 请求头的 OPTIONS 请求才被视为预检请求并以 204 响应，
+// 其他 OPTIONS 请求会交给后续的处理函数。AllowCredentials 与 "*" 同时使用时 New 会 panic
+func New(config ...Config) core.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	allowAll := false
+	var origins []originMatcher
+	for _, origin := range cfg.AllowOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		origins = append(origins, newOriginMatcher(origin))
+	}
+	if allowAll && cfg.AllowCredentials {
+		panic("cors: AllowCredentials 不能与 AllowOrigins 中的 \"*\" 同时使用，否则任意网站都能携带凭据访问")
+	}
+
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(cfg.MaxAge)
+	} else if cfg.MaxAge < 0 {
+		maxAge = "0"
+	}
+
+	// allowOrigin 返回 Access-Control-Allow-Origin 的值，来源不被允许时返回空字符串
+	allowOrigin := func(origin string) string {
+		if allowAll {
+			return "*"
+		}
+		lower := strings.ToLower(origin)
+		for _, m := range origins {
+			if m.match(lower) {
+				return origin
+			}
+		}
+		if cfg.AllowOriginsFunc != nil && cfg.AllowOriginsFunc(origin) {
+			return origin
+		}
+		return ""
+	}
+
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			// 设置 CORS 头
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+			// 如果 Next 返回 true，则跳过此中间件
+			if cfg.Next != nil {
+				if ctx := kanggo.ContextOf(w); ctx != nil && cfg.Next(ctx) {
+					next(w, r)
+					return
+				}
+			}
+
+			header := w.Header()
+			origin := r.Header.Get(constants.HeaderOrigin)
+			preflight := r.Method == http.MethodOptions && origin != "" &&
+				r.Header.Get(constants.HeaderAccessControlRequestMethod) != ""
 
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
+			// 响应头随 Origin 变化，必须告知缓存按 Origin 区分响应
+			header.Add(constants.HeaderVary, constants.HeaderOrigin)
+
+			if !preflight {
+				if origin != "" {
+					if allowed := allowOrigin(origin); allowed != "" {
+						header.Set(constants.HeaderAccessControlAllowOrigin, allowed)
+						if cfg.AllowCredentials {
+							header.Set(constants.HeaderAccessControlAllowCredentials, "true")
+						}
+						if exposeHeaders != "" {
+							header.Set(constants.HeaderAccessControlExposeHeaders, exposeHeaders)
+						}
+					}
+				}
+				next(w, r)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			// 预检请求：不交给后续处理函数，来源不被允许时不返回任何 CORS 响应头，浏览器会拒绝实际请求
+			header.Add(constants.HeaderVary, constants.HeaderAccessControlRequestMethod)
+			header.Add(constants.HeaderVary, constants.HeaderAccessControlRequestHeaders)
+			if allowed := allowOrigin(origin); allowed != "" {
+				header.Set(constants.HeaderAccessControlAllowOrigin, allowed)
+				header.Set(constants.HeaderAccessControlAllowMethods, allowMethods)
+				if cfg.AllowCredentials {
+					header.Set(constants.HeaderAccessControlAllowCredentials, "true")
+				}
+				if allowHeaders != "" {
+					header.Set(constants.HeaderAccessControlAllowHeaders, allowHeaders)
+				} else if requested := r.Header.Get(constants.HeaderAccessControlRequestHeaders); requested != "" {
+					header.Set(constants.HeaderAccessControlAllowHeaders, requested)
+				}
+				if maxAge != "" {
+					header.Set(constants.HeaderAccessControlMaxAge, maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
 		}
 	}
 }
+
+// originMatcher 匹配一个配置的来源，带 "*" 时匹配任意子域名
+type originMatcher struct {
+	prefix, suffix string
+	wildcard       bool
+}
+
+// newOriginMatcher 解析配置的来源，例如 "https://*.example.com" 拆分为前缀 "https://" 与后缀 ".example.com"
+func newOriginMatcher(origin string) originMatcher {
+	if before, after, found := strings.Cut(origin, "*"); found {
+		return originMatcher{prefix: before, suffix: after, wildcard: true}
+	}
+	return originMatcher{prefix: origin}
+}
+
+// match 判断小写形式的 origin 是否匹配，通配符部分至少包含一个字符且不能跨越 "/" 或 ":"
+func (m originMatcher) match(origin string) bool {
+	if !m.wildcard {
+		return origin == m.prefix
+	}
+	if len(origin) <= len(m.prefix)+len(m.suffix) ||
+		!strings.HasPrefix(origin, m.prefix) || !strings.HasSuffix(origin, m.suffix) {
+		return false
+	}
+	sub := origin[len(m.prefix) : len(origin)-len(m.suffix)]
+	return !strings.ContainsAny(sub, "/:")
+}
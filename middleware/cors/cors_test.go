@@ -3,56 +3,228 @@ package cors
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/7836246/kanggo"
 )
 
-// 测试 CORS 中间件
-func TestCORSMiddleware(t *testing.T) {
-	// 创建 KangGo 实例
+// newApp 创建使用 CORS 中间件的应用，并注册 /test 路由
+func newApp(config ...Config) *kanggo.KangGo {
 	app := kanggo.Default()
-
-	// 使用 CORS 中间件
-	app.Use(New())
-
-	// 注册一个简单的 GET 路由
+	app.Use(New(config...))
 	app.GET("/test", func(ctx *kanggo.Context) error {
 		return ctx.SendString("Hello, KangGo!")
 	})
+	return app
+}
 
-	// 创建一个测试请求
-	req, _ := http.NewRequest("OPTIONS", "/test", nil)
+// serve 发送请求并返回响应，headers 为成对的请求头名称与值
+func serve(app *kanggo.KangGo, method string, headers ...string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "/test", nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
 	resp := httptest.NewRecorder()
-
-	// 处理请求
 	app.Router.ServeHTTP(resp, req)
+	return resp
+}
 
-	// 验证 CORS 头
+// preflight 发送来自 origin 的预检请求
+func preflight(app *kanggo.KangGo, origin string, headers ...string) *httptest.ResponseRecorder {
+	return serve(app, http.MethodOptions, append([]string{
+		"Origin", origin,
+		"Access-Control-Request-Method", http.MethodPut,
+	}, headers...)...)
+}
+
+// 测试默认配置
+func TestCORSMiddleware(t *testing.T) {
+	app := newApp()
+
+	// 预检请求返回 204 与允许的方法
+	resp := preflight(app, "https://example.com", "Access-Control-Request-Headers", "X-Token, Content-Type")
+	if status := resp.Code; status != http.StatusNoContent {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusNoContent)
+	}
 	if origin := resp.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
 		t.Errorf("CORS 头错误: 得到 %v, 期待 %v", origin, "*")
 	}
+	if methods := resp.Header().Get("Access-Control-Allow-Methods"); methods != "GET, POST, HEAD, PUT, DELETE, PATCH" {
+		t.Errorf("Access-Control-Allow-Methods 错误: 得到 %v", methods)
+	}
+	// 未配置 AllowHeaders 时原样返回请求的头
+	if headers := resp.Header().Get("Access-Control-Allow-Headers"); headers != "X-Token, Content-Type" {
+		t.Errorf("Access-Control-Allow-Headers 错误: 得到 %v, 期待 %v", headers, "X-Token, Content-Type")
+	}
+	if maxAge := resp.Header().Get("Access-Control-Max-Age"); maxAge != "" {
+		t.Errorf("默认不应设置 Access-Control-Max-Age, 得到 %v", maxAge)
+	}
+	if resp.Body.Len() != 0 {
+		t.Errorf("预检请求不应交给处理函数, 得到响应内容 %q", resp.Body.String())
+	}
+	vary := strings.Join(resp.Header().Values("Vary"), ", ")
+	for _, want := range []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"} {
+		if !strings.Contains(vary, want) {
+			t.Errorf("Vary 头缺少 %v: 得到 %v", want, vary)
+		}
+	}
 
-	// 验证状态码
+	// 跨域的实际请求
+	resp = serve(app, http.MethodGet, "Origin", "https://example.com")
 	if status := resp.Code; status != http.StatusOK {
 		t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusOK)
 	}
+	if origin := resp.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Errorf("CORS 头错误: 得到 %v, 期待 %v", origin, "*")
+	}
+	if resp.Body.String() != "Hello, KangGo!" {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), "Hello, KangGo!")
+	}
 
-	// 创建一个实际请求来验证 CORS
-	req, _ = http.NewRequest("GET", "/test", nil)
-	resp = httptest.NewRecorder()
+	// 同源请求没有 Origin，不设置 CORS 头
+	resp = serve(app, http.MethodGet)
+	if origin := resp.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("没有 Origin 时不应设置 CORS 头, 得到 %v", origin)
+	}
+}
 
-	// 处理请求
-	app.Router.ServeHTTP(resp, req)
+// 测试不带 Access-Control-Request-Method 的 OPTIONS 请求交给处理函数
+func TestCORSNonPreflightOptions(t *testing.T) {
+	app := newApp()
 
-	// 验证状态码
+	for _, headers := range [][]string{nil, {"Origin", "https://example.com"}} {
+		resp := serve(app, http.MethodOptions, headers...)
+		if status := resp.Code; status != http.StatusOK {
+			t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusOK)
+		}
+		if resp.Body.String() != "Hello, KangGo!" {
+			t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), "Hello, KangGo!")
+		}
+		if methods := resp.Header().Get("Access-Control-Allow-Methods"); methods != "" {
+			t.Errorf("非预检请求不应设置 Access-Control-Allow-Methods, 得到 %v", methods)
+		}
+	}
+}
+
+// 测试允许的来源
+func TestCORSAllowOrigins(t *testing.T) {
+	app := newApp(Config{
+		AllowOrigins: []string{"https://example.com", "https://*.example.org"},
+		AllowOriginsFunc: func(origin string) bool {
+			return origin == "http://localhost:3000"
+		},
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://example.com", true},
+		{"HTTPS://EXAMPLE.COM", true},
+		{"https://api.example.org", true},
+		{"https://a.b.example.org", true},
+		{"http://localhost:3000", true},
+		{"https://example.org", false},
+		{"https://evil.com/.example.org", false},
+		{"https://evil.com:.example.org", false},
+		{"http://example.com", false},
+		{"https://example.com.evil.com", false},
+		{"http://localhost:8080", false},
+	}
+	for _, tt := range tests {
+		want := ""
+		if tt.allowed {
+			want = tt.origin
+		}
+
+		resp := serve(app, http.MethodGet, "Origin", tt.origin)
+		if origin := resp.Header().Get("Access-Control-Allow-Origin"); origin != want {
+			t.Errorf("来源 %v 的 CORS 头错误: 得到 %q, 期待 %q", tt.origin, origin, want)
+		}
+		if vary := resp.Header().Get("Vary"); vary != "Origin" {
+			t.Errorf("来源 %v 的 Vary 头错误: 得到 %v, 期待 %v", tt.origin, vary, "Origin")
+		}
+
+		// 来源不被允许的预检请求也返回 204，但不带任何 CORS 头
+		resp = preflight(app, tt.origin)
+		if status := resp.Code; status != http.StatusNoContent {
+			t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusNoContent)
+		}
+		if origin := resp.Header().Get("Access-Control-Allow-Origin"); origin != want {
+			t.Errorf("来源 %v 的预检 CORS 头错误: 得到 %q, 期待 %q", tt.origin, origin, want)
+		}
+		if methods := resp.Header().Get("Access-Control-Allow-Methods"); (methods != "") != tt.allowed {
+			t.Errorf("来源 %v 的 Access-Control-Allow-Methods 错误: 得到 %q", tt.origin, methods)
+		}
+	}
+}
+
+// 测试凭据、暴露的响应头、允许的请求头与预检缓存时间
+func TestCORSCredentials(t *testing.T) {
+	app := newApp(Config{
+		AllowOrigins:     []string{"https://example.com"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost},
+		AllowHeaders:     []string{"Content-Type", "X-Token"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Total-Count", "X-Request-Id"},
+		MaxAge:           600,
+	})
+
+	resp := preflight(app, "https://example.com", "Access-Control-Request-Headers", "X-Other")
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Content-Type, X-Token",
+		"Access-Control-Max-Age":           "600",
+	}
+	for name, want := range expected {
+		if got := resp.Header().Get(name); got != want {
+			t.Errorf("预检请求的 %v 错误: 得到 %q, 期待 %q", name, got, want)
+		}
+	}
+
+	resp = serve(app, http.MethodGet, "Origin", "https://example.com")
+	expected = map[string]string{
+		"Access-Control-Allow-Origin":      "https://example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Expose-Headers":    "X-Total-Count, X-Request-Id",
+	}
+	for name, want := range expected {
+		if got := resp.Header().Get(name); got != want {
+			t.Errorf("实际请求的 %v 错误: 得到 %q, 期待 %q", name, got, want)
+		}
+	}
+
+	// MaxAge 为负数时禁止缓存预检结果
+	resp = preflight(newApp(Config{MaxAge: -1}), "https://example.com")
+	if maxAge := resp.Header().Get("Access-Control-Max-Age"); maxAge != "0" {
+		t.Errorf("Access-Control-Max-Age 错误: 得到 %q, 期待 %q", maxAge, "0")
+	}
+}
+
+// 测试 AllowCredentials 与 "*" 同时使用时 panic
+func TestCORSCredentialsWithWildcard(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("AllowCredentials 与 \"*\" 同时使用时应 panic")
+		}
+	}()
+	New(Config{AllowCredentials: true})
+}
+
+// 测试 Next 跳过中间件
+func TestCORSNext(t *testing.T) {
+	app := newApp(Config{
+		Next: func(c *kanggo.Context) bool { return true },
+	})
+
+	resp := preflight(app, "https://example.com")
 	if status := resp.Code; status != http.StatusOK {
 		t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusOK)
 	}
-
-	// 验证响应内容
-	expected := "Hello, KangGo!"
-	if resp.Body.String() != expected {
-		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), expected)
+	if origin := resp.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("跳过中间件时不应设置 CORS 头, 得到 %v", origin)
 	}
 }
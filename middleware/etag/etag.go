@@ -8,17 +8,33 @@ import (
 )
 
 // New ETag 中间件函数，根据响应内容计算 ETag 并将其添加到响应头
+// 已经调用过 Flush 的流式响应会直接发送给客户端，不计算 ETag
 func New() func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			// 创建一个 ResponseRecorder 来捕获响应
 			recorder := NewResponseRecorder(w)
 
-			// 检查请求头中的 If-None-Match
-			body := recorder.Body.Bytes()
-			hash := md5.Sum(body)
+			// 调用下一个中间件/处理程序
+			next(recorder, r)
+
+			// 流式响应已经发送完毕
+			if recorder.Streaming() {
+				return
+			}
+
+			// 只为成功的响应计算 ETag
+			if status := recorder.Status(); status < 200 || status >= 300 {
+				recorder.WriteToResponse(w)
+				return
+			}
+
+			// 根据响应内容计算 ETag
+			hash := md5.Sum(recorder.Body.Bytes())
 			etag := `"` + hex.EncodeToString(hash[:]) + `"`
+			w.Header().Set("ETag", etag)
 
+			// 检查请求头中的 If-None-Match
 			if match := r.Header.Get("If-None-Match"); match != "" {
 				if strings.Contains(match, etag) {
 					// 设置状态码为 304 并返回
@@ -27,12 +43,6 @@ func New() func(http.HandlerFunc) http.HandlerFunc {
 				}
 			}
 
-			// 调用下一个中间件/处理程序
-			next(recorder, r)
-
-			// 设置 ETag 响应头
-			w.Header().Set("ETag", etag)
-
 			// 将捕获的响应写回客户端
 			recorder.WriteToResponse(w)
 		}
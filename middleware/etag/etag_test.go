@@ -50,3 +50,33 @@ func TestETagMiddleware(t *testing.T) {
 		t.Errorf("状态码错误: 得到 %v, 期待 %v", status, http.StatusNotModified)
 	}
 }
+
+// 测试流式响应经过 ETag 中间件后依然可以刷新
+func TestETagMiddlewareStreaming(t *testing.T) {
+	app := kanggo.Default()
+	app.Use(New())
+
+	app.GET("/stream", func(ctx *kanggo.Context) error {
+		stream := ctx.NDJSON(http.StatusOK)
+		for i := 0; i < 2; i++ {
+			if err := stream.Encode(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, req)
+
+	if !resp.Flushed {
+		t.Error("流式响应未刷新")
+	}
+	if body := resp.Body.String(); body != "0\n1\n" {
+		t.Errorf("响应内容错误: 得到 %q, 期待 %q", body, "0\n1\n")
+	}
+	if etag := resp.Header().Get("ETag"); etag != "" {
+		t.Errorf("流式响应不应生成 ETag: 得到 %v", etag)
+	}
+}
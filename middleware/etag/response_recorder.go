@@ -2,30 +2,92 @@ package etag
 
 import (
 	"bytes"
+	"io"
 	"net/http"
+
+	"github.com/7836246/kanggo/core"
 )
 
 // ResponseRecorder 是一个用于捕获 HTTP 响应的自定义结构体
+// 响应内容先写入 Body 缓冲区；一旦处理程序调用 Flush，记录器转为直通模式，后续内容直接写给客户端
+// 嵌入的 core.ResponseWriter 记录实际发送的状态码与字节数，并提供 Hijack、Push 与 Unwrap
 type ResponseRecorder struct {
-	http.ResponseWriter
-	Body *bytes.Buffer
+	*core.ResponseWriter
+	Body      *bytes.Buffer
+	status    int  // 处理程序设置的状态码，0 表示尚未设置
+	streaming bool // 是否已进入直通模式
 }
 
 // NewResponseRecorder 创建一个新的 ResponseRecorder
 func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
 	return &ResponseRecorder{
-		ResponseWriter: w,
+		ResponseWriter: core.NewResponseWriter(w),
 		Body:           &bytes.Buffer{},
 	}
 }
 
-// Write 捕获写入的内容
+// WriteHeader 记录状态码，直通模式下直接写给客户端
+func (rec *ResponseRecorder) WriteHeader(code int) {
+	if rec.streaming {
+		rec.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+// Write 捕获写入的内容，直通模式下直接写给客户端
 func (rec *ResponseRecorder) Write(p []byte) (int, error) {
-	rec.Body.Write(p)
-	return rec.ResponseWriter.Write(p)
+	if rec.streaming {
+		return rec.ResponseWriter.Write(p)
+	}
+	return rec.Body.Write(p)
 }
 
-// WriteToResponse 将捕获的内容写回原始的 ResponseWriter
+// WriteString 捕获写入的字符串，直通模式下直接写给客户端
+func (rec *ResponseRecorder) WriteString(s string) (int, error) {
+	if rec.streaming {
+		return rec.ResponseWriter.WriteString(s)
+	}
+	return rec.Body.WriteString(s)
+}
+
+// ReadFrom 捕获从 r 读取的内容，直通模式下直接写给客户端
+func (rec *ResponseRecorder) ReadFrom(r io.Reader) (int64, error) {
+	if rec.streaming {
+		return rec.ResponseWriter.ReadFrom(r)
+	}
+	return rec.Body.ReadFrom(r)
+}
+
+// Flush 将已捕获的内容发送给客户端并转为直通模式，使流式响应在经过 ETag 中间件后依然可以实时刷新
+func (rec *ResponseRecorder) Flush() {
+	if !rec.streaming {
+		rec.streaming = true
+		rec.WriteToResponse(rec.ResponseWriter)
+	}
+	rec.ResponseWriter.Flush()
+}
+
+// Status 返回处理程序设置的状态码，未设置时返回 200
+func (rec *ResponseRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// Streaming 返回记录器是否已因 Flush 转为直通模式
+func (rec *ResponseRecorder) Streaming() bool {
+	return rec.streaming
+}
+
+// WriteToResponse 将捕获的状态码和内容写回原始的 ResponseWriter
 func (rec *ResponseRecorder) WriteToResponse(w http.ResponseWriter) {
+	if rec.status != 0 {
+		w.WriteHeader(rec.status)
+	}
 	w.Write(rec.Body.Bytes())
+	rec.Body.Reset()
 }
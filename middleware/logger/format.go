@@ -0,0 +1,368 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// 日志格式中可以使用的标签，写作 ${status} 的形式
+const (
+	TagTime      = "time"       // 请求开始的时间，按 Config.TimeFormat 格式化
+	TagStatus    = "status"     // 响应状态码
+	TagMethod    = "method"     // 请求方法
+	TagPath      = "path"       // 请求路径，不含查询字符串
+	TagURI       = "uri"        // 请求 URI，包含查询字符串
+	TagProtocol  = "protocol"   // 协议版本，例如 HTTP/1.1
+	TagHost      = "host"       // 请求的主机名
+	TagBytes     = "bytes"      // 响应体字节数
+	TagLatency   = "latency"    // 处理时间
+	TagIP        = "ip"         // 客户端 IP
+	TagUser      = "user"       // Basic 认证的用户名
+	TagUserAgent = "ua"         // User-Agent 请求头
+	TagReferer   = "referer"    // Referer 请求头
+	TagRequestID = "request_id" // 请求 ID，优先读取响应头 X-Request-ID，其次读取请求头
+	TagHeader    = "header:"    // 任意请求头，例如 ${header:Accept-Language}
+)
+
+// 预定义的日志格式
+const (
+	// FormatDefault 默认格式，输出到终端时状态码与请求方法带颜色
+	FormatDefault = "${time} | ${status} | ${latency} | ${ip} | ${method} ${uri}"
+	// FormatCommon Apache 通用日志格式（Common Log Format）
+	FormatCommon = `${ip} - ${user} [${time}] "${method} ${uri} ${protocol}" ${status} ${bytes}`
+	// FormatCombined Apache 组合日志格式，在通用格式后追加 Referer 与 User-Agent
+	FormatCombined = FormatCommon + ` "${referer}" "${ua}"`
+	// FormatJSON 每个请求输出一行 JSON
+	FormatJSON = "json"
+	// FormatLogfmt 每个请求输出一行 logfmt 格式的 key=value
+	FormatLogfmt = "logfmt"
+)
+
+// 各格式未设置 Config.TimeFormat 时使用的时间格式
+const (
+	DefaultTimeFormat = "2006-01-02 15:04:05"
+	ApacheTimeFormat  = "02/Jan/2006:15:04:05 -0700"
+)
+
+// 终端颜色的 ANSI 转义序列
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+)
+
+// entry 是一个请求的日志数据
+type entry struct {
+	Time      time.Time
+	Status    int
+	Method    string
+	Path      string
+	URI       string
+	Protocol  string
+	Host      string
+	Bytes     int64
+	Latency   time.Duration
+	IP        string
+	User      string
+	UserAgent string
+	Referer   string
+	RequestID string
+	request   *http.Request
+}
+
+// newEntry 从请求与响应中收集日志数据，proxyHeader 不为空时从该请求头读取客户端 IP
+// path 与 uri 必须在调用处理函数之前读取，文件路由会就地去掉 r.URL.Path 中的前缀
+func newEntry(r *http.Request, path, uri string, header http.Header, start time.Time, status int, size int64, proxyHeader string) *entry {
+	e := &entry{
+		Time:      start,
+		Status:    status,
+		Method:    r.Method,
+		Path:      path,
+		URI:       uri,
+		Protocol:  r.Proto,
+		Host:      r.Host,
+		Bytes:     size,
+		Latency:   time.Since(start),
+		IP:        clientIP(r, proxyHeader),
+		UserAgent: r.UserAgent(),
+		Referer:   r.Referer(),
+		request:   r,
+	}
+	e.User, _, _ = r.BasicAuth()
+	if e.RequestID = header.Get(constants.HeaderXRequestID); e.RequestID == "" {
+		e.RequestID = r.Header.Get(constants.HeaderXRequestID)
+	}
+	return e
+}
+
+// clientIP 返回客户端 IP；代理请求头中有多个地址时取第一个，即最初的客户端
+func clientIP(r *http.Request, proxyHeader string) string {
+	if proxyHeader != "" {
+		if value := r.Header.Get(proxyHeader); value != "" {
+			ip, _, _ := strings.Cut(value, ",")
+			return strings.TrimSpace(ip)
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// segment 是解析后的日志格式片段，tag 为空时输出 text 原文
+type segment struct {
+	text string
+	tag  string
+}
+
+// parseFormat 将 "${tag}" 形式的格式解析为片段，遇到未知标签或未闭合的 "${" 时返回错误
+func parseFormat(format string) ([]segment, error) {
+	var segments []segment
+	for format != "" {
+		start := strings.Index(format, "${")
+		if start < 0 {
+			segments = append(segments, segment{text: format})
+			break
+		}
+		if start > 0 {
+			segments = append(segments, segment{text: format[:start]})
+		}
+		end := strings.IndexByte(format[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("日志格式中的 %q 缺少 }", format[start:])
+		}
+		tag := format[start+2 : start+end]
+		if !validTag(tag) {
+			return nil, fmt.Errorf("未知的日志标签 ${%s}", tag)
+		}
+		segments = append(segments, segment{tag: tag})
+		format = format[start+end+1:]
+	}
+	return segments, nil
+}
+
+// validTag 判断标签是否受支持
+func validTag(tag string) bool {
+	switch tag {
+	case TagTime, TagStatus, TagMethod, TagPath, TagURI, TagProtocol, TagHost, TagBytes,
+		TagLatency, TagIP, TagUser, TagUserAgent, TagReferer, TagRequestID:
+		return true
+	}
+	return strings.HasPrefix(tag, TagHeader) && len(tag) > len(TagHeader)
+}
+
+// writeTemplate 按解析后的格式输出一行日志，值为空的文本标签输出 "-"
+func writeTemplate(buf *bytes.Buffer, segments []segment, e *entry, timeFormat string, colors bool) {
+	for _, s := range segments {
+		if s.tag == "" {
+			buf.WriteString(s.text)
+			continue
+		}
+		switch s.tag {
+		case TagTime:
+			buf.WriteString(e.Time.Format(timeFormat))
+		case TagStatus:
+			if colors {
+				buf.WriteString(statusColor(e.Status))
+				buf.WriteString(strconv.Itoa(e.Status))
+				buf.WriteString(colorReset)
+			} else {
+				buf.WriteString(strconv.Itoa(e.Status))
+			}
+		case TagMethod:
+			if colors {
+				buf.WriteString(colorBlue + e.Method + colorReset)
+			} else {
+				buf.WriteString(e.Method)
+			}
+		case TagBytes:
+			buf.WriteString(strconv.FormatInt(e.Bytes, 10))
+		case TagLatency:
+			buf.WriteString(e.Latency.String())
+		default:
+			writeValue(buf, e.text(s.tag))
+		}
+	}
+	buf.WriteByte('\n')
+}
+
+// text 返回文本类标签的值
+func (e *entry) text(tag string) string {
+	switch tag {
+	case TagPath:
+		return e.Path
+	case TagURI:
+		return e.URI
+	case TagProtocol:
+		return e.Protocol
+	case TagHost:
+		return e.Host
+	case TagIP:
+		return e.IP
+	case TagUser:
+		return e.User
+	case TagUserAgent:
+		return e.UserAgent
+	case TagReferer:
+		return e.Referer
+	case TagRequestID:
+		return e.RequestID
+	}
+	return e.request.Header.Get(strings.TrimPrefix(tag, TagHeader))
+}
+
+// writeValue 输出文本值，空值输出 "-"，与 Apache 日志的约定一致
+// 引号与控制字符按 Go 字符串的规则转义，防止 User-Agent 等请求头中的换行伪造日志行
+func writeValue(buf *bytes.Buffer, value string) {
+	switch {
+	case value == "":
+		buf.WriteByte('-')
+	case strings.ContainsAny(value, "\"\\") || strings.ContainsFunc(value, isControl):
+		quoted := strconv.Quote(value)
+		buf.WriteString(quoted[1 : len(quoted)-1])
+	default:
+		buf.WriteString(value)
+	}
+}
+
+// statusColor 返回状态码对应的颜色：2xx 绿色、3xx 青色、4xx 黄色、5xx 红色
+func statusColor(status int) string {
+	switch {
+	case status >= 500:
+		return colorRed
+	case status >= 400:
+		return colorYellow
+	case status >= 300:
+		return colorCyan
+	default:
+		return colorGreen
+	}
+}
+
+// jsonEntry 是 JSON 格式输出的字段，空的文本字段省略
+type jsonEntry struct {
+	Time      string `json:"time"`
+	Status    int    `json:"status"`
+	Method    string `json:"method"`
+	URI       string `json:"uri"`
+	Protocol  string `json:"protocol"`
+	Host      string `json:"host,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	User      string `json:"user,omitempty"`
+	Bytes     int64  `json:"bytes"`
+	Latency   string `json:"latency"`
+	UserAgent string `json:"ua,omitempty"`
+	Referer   string `json:"referer,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeJSON 输出一行 JSON，Encode 会在末尾追加换行
+func writeJSON(buf *bytes.Buffer, e *entry, timeFormat string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(jsonEntry{
+		Time:      e.Time.Format(timeFormat),
+		Status:    e.Status,
+		Method:    e.Method,
+		URI:       e.URI,
+		Protocol:  e.Protocol,
+		Host:      e.Host,
+		IP:        e.IP,
+		User:      e.User,
+		Bytes:     e.Bytes,
+		Latency:   e.Latency.String(),
+		UserAgent: e.UserAgent,
+		Referer:   e.Referer,
+		RequestID: e.RequestID,
+	})
+}
+
+// writeLogfmt 输出一行 logfmt，与 JSON 格式的字段相同，空的文本字段省略
+func writeLogfmt(buf *bytes.Buffer, e *entry, timeFormat string) {
+	first := true
+	pair := func(key, value string) {
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		if value == "" || strings.ContainsAny(value, " =\"\\") || strings.ContainsFunc(value, isControl) {
+			buf.WriteString(strconv.Quote(value))
+		} else {
+			buf.WriteString(value)
+		}
+	}
+	optional := func(key, value string) {
+		if value != "" {
+			pair(key, value)
+		}
+	}
+
+	pair("time", e.Time.Format(timeFormat))
+	pair("status", strconv.Itoa(e.Status))
+	pair("method", e.Method)
+	pair("uri", e.URI)
+	pair("protocol", e.Protocol)
+	optional("host", e.Host)
+	optional("ip", e.IP)
+	optional("user", e.User)
+	pair("bytes", strconv.FormatInt(e.Bytes, 10))
+	pair("latency", e.Latency.String())
+	optional("ua", e.UserAgent)
+	optional("referer", e.Referer)
+	optional("request_id", e.RequestID)
+	buf.WriteByte('\n')
+}
+
+// isControl 判断字符是否为需要转义的控制字符
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+// attrs 返回交给 slog.Handler 的字段，空的文本字段省略
+func (e *entry) attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.Int("status", e.Status),
+		slog.String("method", e.Method),
+		slog.String("uri", e.URI),
+		slog.String("protocol", e.Protocol),
+	}
+	optional := func(key, value string) {
+		if value != "" {
+			attrs = append(attrs, slog.String(key, value))
+		}
+	}
+	optional("host", e.Host)
+	optional("ip", e.IP)
+	optional("user", e.User)
+	attrs = append(attrs, slog.Int64("bytes", e.Bytes), slog.Duration("latency", e.Latency))
+	optional("ua", e.UserAgent)
+	optional("referer", e.Referer)
+	optional("request_id", e.RequestID)
+	return attrs
+}
+
+// level 返回状态码对应的 slog 日志级别：5xx 为 Error，4xx 为 Warn，其他为 Info
+func (e *entry) level() slog.Level {
+	switch {
+	case e.Status >= 500:
+		return slog.LevelError
+	case e.Status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -1,19 +1,166 @@
 package logger
 
 import (
-	"github.com/7836246/kanggo/core"
-	"log"
+	"bytes"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"sync"
 	"time"
+
+	"github.com/7836246/kanggo"
+	"github.com/7836246/kanggo/core"
 )
 
-// New Logger 中间件，用于记录请求处理时间
-func New() core.MiddlewareFunc {
+// Config 是 Logger 中间件的配置结构体
+type Config struct {
+	Next          func(c *kanggo.Context) bool           // 可选：跳过此中间件的函数，在处理请求之前调用
+	Skip          func(r *http.Request, status int) bool // 可选：处理完请求后调用，返回 true 时不记录日志，例如只记录错误请求
+	SkipPaths     []string                               // 可选：不记录日志的请求路径，例如健康检查 "/health"
+	Format        string                                 // 可选：日志格式，预定义格式或由 ${tag} 组成的自定义格式，默认值 FormatDefault
+	TimeFormat    string                                 // 可选：${time} 的时间格式，默认按 Format 选择 DefaultTimeFormat、ApacheTimeFormat 或 time.RFC3339Nano
+	Output        io.Writer                              // 可选：日志输出位置，默认值 os.Stdout
+	Handler       slog.Handler                           // 可选：设置后以结构化记录交给 slog.Handler，忽略 Format 与 Output
+	ProxyHeader   string                                 // 可选：读取客户端 IP 的请求头，例如 "X-Forwarded-For"，只应在可信代理之后使用，默认使用连接地址
+	DisableColors bool                                   // 可选：禁用颜色，默认在 Output 为终端且未设置 NO_COLOR 环境变量时为 ${status} 与 ${method} 着色
+}
+
+// ConfigDefault 默认配置
+var ConfigDefault = Config{
+	Next:          nil,
+	Skip:          nil,
+	SkipPaths:     nil,
+	Format:        FormatDefault,
+	TimeFormat:    "",
+	Output:        os.Stdout,
+	Handler:       nil,
+	ProxyHeader:   "",
+	DisableColors: false,
+}
+
+// configDefault 为未设置的配置项填充默认值
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+
+	if len(config) > 0 {
+		cfg = config[0]
+
+		if cfg.Format == "" {
+			cfg.Format = ConfigDefault.Format
+		}
+
+		if cfg.Output == nil {
+			cfg.Output = ConfigDefault.Output
+		}
+	}
+
+	if cfg.TimeFormat == "" {
+		switch cfg.Format {
+		case FormatCommon, FormatCombined:
+			cfg.TimeFormat = ApacheTimeFormat
+		case FormatJSON, FormatLogfmt:
+			cfg.TimeFormat = time.RFC3339Nano
+		default:
+			cfg.TimeFormat = DefaultTimeFormat
+		}
+	}
+
+	return cfg
+}
+
+// New 创建一个新的 Logger 中间件，记录请求的状态码、响应大小、处理时间等信息
+// 自定义格式中包含未知的标签时 New 会 panic
+func New(config ...Config) core.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	var segments []segment
+	if cfg.Handler == nil && cfg.Format != FormatJSON && cfg.Format != FormatLogfmt {
+		var err error
+		if segments, err = parseFormat(cfg.Format); err != nil {
+			panic("logger: " + err.Error())
+		}
+	}
+
+	skipPaths := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skipPaths[p] = struct{}{}
+	}
+
+	colors := !cfg.DisableColors && isTerminal(cfg.Output)
+
+	var logger *slog.Logger
+	if cfg.Handler != nil {
+		logger = slog.New(cfg.Handler)
+	}
+
+	// 多个请求并发写入同一个 Output，整行写入时加锁避免内容交错
+	var mu sync.Mutex
+	bufPool := sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
+			// 如果 Next 返回 true，则跳过此中间件
+			if cfg.Next != nil {
+				if ctx := kanggo.ContextOf(w); ctx != nil && cfg.Next(ctx) {
+					next(w, r)
+					return
+				}
+			}
+			if _, ok := skipPaths[r.URL.Path]; ok {
+				next(w, r)
+				return
+			}
+
+			// 处理函数可能修改 r.URL，例如文件路由会去掉挂载前缀，因此先记录原始的路径
+			path, uri := r.URL.Path, r.RequestURI
+			if uri == "" {
+				uri = r.URL.RequestURI()
+			}
+
 			start := time.Now()
-			next(w, r) // 调用下一个处理器
-			log.Printf("请求 %s %s 处理时间: %v\n", r.Method, r.URL.Path, time.Since(start))
+			rw := core.NewResponseWriter(w)
+			next(rw, r) // 调用下一个处理器
+
+			if cfg.Skip != nil && cfg.Skip(r, rw.Status()) {
+				return
+			}
+			e := newEntry(r, path, uri, rw.Header(), start, rw.Status(), rw.Size(), cfg.ProxyHeader)
+
+			if logger != nil {
+				logger.LogAttrs(r.Context(), e.level(), "request", e.attrs()...)
+				return
+			}
+
+			buf := bufPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			switch cfg.Format {
+			case FormatJSON:
+				writeJSON(buf, e, cfg.TimeFormat)
+			case FormatLogfmt:
+				writeLogfmt(buf, e, cfg.TimeFormat)
+			default:
+				writeTemplate(buf, segments, e, cfg.TimeFormat, colors)
+			}
+			mu.Lock()
+			_, _ = cfg.Output.Write(buf.Bytes())
+			mu.Unlock()
+			bufPool.Put(buf)
 		}
 	}
 }
+
+// isTerminal 判断 w 是否为终端，设置了 NO_COLOR 环境变量时视为不支持颜色
+func isTerminal(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -2,32 +2,54 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/7836246/kanggo"
 )
 
-// 测试 Logger 中间件
-func TestLoggerMiddleware(t *testing.T) {
-	// 创建 KangGo 实例
+// newApp 创建使用 Logger 中间件的应用，注册 /test、/missing 与 /health 路由
+func newApp(config ...Config) *kanggo.KangGo {
 	app := kanggo.Default()
-
-	// 使用 Logger 中间件
-	app.Use(New())
-
-	// 注册一个简单的 GET 路由
+	app.Use(New(config...))
 	app.GET("/test", func(ctx *kanggo.Context) error {
+		ctx.Writer.Header().Set("X-Request-ID", "req-1")
 		return ctx.SendString("Hello, KangGo!")
 	})
+	app.GET("/missing", func(ctx *kanggo.Context) error {
+		return kanggo.ErrNotFound
+	})
+	app.GET("/health", func(ctx *kanggo.Context) error {
+		return ctx.SendString("ok")
+	})
+	return app
+}
 
-	// 创建一个测试请求
-	req, _ := http.NewRequest("GET", "/test", nil)
+// serve 发送带常用请求头的请求并返回响应
+func serve(app *kanggo.KangGo, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "kanggo-test")
+	req.Header.Set("Referer", "https://example.com/")
+	req.SetBasicAuth("alice", "secret")
 	resp := httptest.NewRecorder()
-
-	// 处理请求
 	app.Router.ServeHTTP(resp, req)
+	return resp
+}
+
+// 测试 Logger 中间件
+func TestLoggerMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	app := newApp(Config{Output: &out})
+
+	resp := serve(app, "/test?q=1")
 
 	// 验证响应状态码
 	if status := resp.Code; status != http.StatusOK {
@@ -39,4 +61,206 @@ func TestLoggerMiddleware(t *testing.T) {
 	if resp.Body.String() != expected {
 		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), expected)
 	}
+
+	line := out.String()
+	for _, want := range []string{" | 200 | ", " | 192.0.2.1 | ", "GET /test?q=1\n"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("日志缺少 %q: 得到 %q", want, line)
+		}
+	}
+	if strings.Contains(line, "\033[") {
+		t.Errorf("输出不是终端时不应带颜色: 得到 %q", line)
+	}
+}
+
+// 测试 Apache 通用与组合日志格式
+func TestLoggerApacheFormats(t *testing.T) {
+	var out bytes.Buffer
+	serve(newApp(Config{Output: &out, Format: FormatCommon}), "/test")
+
+	line := out.String()
+	prefix := "192.0.2.1 - alice ["
+	suffix := `] "GET /test HTTP/1.1" 200 14` + "\n"
+	if !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, suffix) {
+		t.Fatalf("通用日志格式错误: 得到 %q", line)
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(line, prefix), suffix)
+	if _, err := time.Parse(ApacheTimeFormat, stamp); err != nil {
+		t.Errorf("时间格式错误: %v", err)
+	}
+
+	out.Reset()
+	serve(newApp(Config{Output: &out, Format: FormatCombined}), "/test")
+	if want := `200 14 "https://example.com/" "kanggo-test"` + "\n"; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("组合日志格式错误: 得到 %q, 期待以 %q 结尾", out.String(), want)
+	}
+}
+
+// 测试 JSON 格式
+func TestLoggerJSON(t *testing.T) {
+	var out bytes.Buffer
+	serve(newApp(Config{Output: &out, Format: FormatJSON}), "/test?q=<b>")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("解析 JSON 日志失败: %v, 内容 %q", err, out.String())
+	}
+	expected := map[string]interface{}{
+		"status":     float64(200),
+		"method":     "GET",
+		"uri":        "/test?q=<b>",
+		"ip":         "192.0.2.1",
+		"user":       "alice",
+		"bytes":      float64(14),
+		"ua":         "kanggo-test",
+		"request_id": "req-1",
+	}
+	for key, want := range expected {
+		if line[key] != want {
+			t.Errorf("字段 %v 错误: 得到 %v, 期待 %v", key, line[key], want)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, line["time"].(string)); err != nil {
+		t.Errorf("时间格式错误: %v", err)
+	}
+	if _, err := time.ParseDuration(line["latency"].(string)); err != nil {
+		t.Errorf("处理时间格式错误: %v", err)
+	}
+}
+
+// 测试 logfmt 格式
+func TestLoggerLogfmt(t *testing.T) {
+	var out bytes.Buffer
+	serve(newApp(Config{Output: &out, Format: FormatLogfmt}), "/missing")
+
+	line := out.String()
+	for _, want := range []string{"status=404 ", "method=GET ", "uri=/missing ", "ip=192.0.2.1 ", "ua=kanggo-test ", `referer=https://example.com/`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("日志缺少 %q: 得到 %q", want, line)
+		}
+	}
+	if !strings.HasPrefix(line, "time=") || !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+		t.Errorf("logfmt 格式错误: 得到 %q", line)
+	}
+}
+
+// 测试自定义格式与请求头中的换行转义
+func TestLoggerCustomFormat(t *testing.T) {
+	var out bytes.Buffer
+	app := newApp(Config{
+		Output:      &out,
+		Format:      "${request_id} ${status} ${path} ${header:X-Trace} ${header:X-Missing} ${ip}",
+		ProxyHeader: "X-Forwarded-For",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Trace", "a\nb")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	app.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := `req-1 200 /test a\nb - 203.0.113.9` + "\n"; out.String() != want {
+		t.Errorf("自定义格式错误: 得到 %q, 期待 %q", out.String(), want)
+	}
+
+	for _, format := range []string{"${unknown}", "${status", "${header:}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("格式 %q 无效时应 panic", format)
+				}
+			}()
+			New(Config{Format: format})
+		}()
+	}
+}
+
+// 测试文件路由去掉前缀后，日志中仍记录完整的请求路径
+func TestLoggerStaticFS(t *testing.T) {
+	var out bytes.Buffer
+	app := kanggo.Default()
+	app.Use(New(Config{Output: &out, Format: "${path} ${uri} ${status}"}))
+	app.StaticFS("/static", fstest.MapFS{"x.txt": {Data: []byte("x")}})
+
+	req := httptest.NewRequest(http.MethodGet, "/static/x.txt?v=1", nil)
+	app.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := "/static/x.txt /static/x.txt?v=1 200\n"; out.String() != want {
+		t.Errorf("日志内容错误: 得到 %q, 期待 %q", out.String(), want)
+	}
+}
+
+// 测试 slog.Handler 输出与日志级别
+func TestLoggerSlogHandler(t *testing.T) {
+	var out bytes.Buffer
+	app := newApp(Config{Handler: slog.NewJSONHandler(&out, nil)})
+
+	serve(app, "/test")
+	serve(app, "/missing")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("日志行数错误: 得到 %v, 期待 %v", len(lines), 2)
+	}
+	for i, want := range []struct {
+		level  string
+		status float64
+	}{{"INFO", 200}, {"WARN", 404}} {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+			t.Fatalf("解析 slog 日志失败: %v", err)
+		}
+		if record["level"] != want.level || record["status"] != want.status || record["msg"] != "request" {
+			t.Errorf("slog 日志错误: 得到 %v", record)
+		}
+	}
+}
+
+// 测试跳过规则
+func TestLoggerSkip(t *testing.T) {
+	var out bytes.Buffer
+	app := newApp(Config{
+		Output:    &out,
+		SkipPaths: []string{"/health"},
+		Skip: func(r *http.Request, status int) bool {
+			return status < http.StatusBadRequest
+		},
+	})
+
+	serve(app, "/health")
+	serve(app, "/test")
+	if out.Len() != 0 {
+		t.Errorf("不应记录被跳过的请求: 得到 %q", out.String())
+	}
+
+	serve(app, "/missing")
+	if !strings.Contains(out.String(), " | 404 | ") {
+		t.Errorf("应记录错误请求: 得到 %q", out.String())
+	}
+
+	out.Reset()
+	app = newApp(Config{
+		Output: &out,
+		Next:   func(c *kanggo.Context) bool { return true },
+	})
+	if resp := serve(app, "/test"); resp.Code != http.StatusOK || out.Len() != 0 {
+		t.Errorf("Next 返回 true 时应跳过中间件: 状态码 %v, 日志 %q", resp.Code, out.String())
+	}
+}
+
+// 测试终端输出的颜色
+func TestLoggerColors(t *testing.T) {
+	segments, err := parseFormat("${status} ${method}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	writeTemplate(&buf, segments, &entry{Status: 503, Method: "GET"}, DefaultTimeFormat, true)
+	if want := colorRed + "503" + colorReset + " " + colorBlue + "GET" + colorReset + "\n"; buf.String() != want {
+		t.Errorf("带颜色的日志错误: 得到 %q, 期待 %q", buf.String(), want)
+	}
+
+	if isTerminal(&buf) {
+		t.Error("bytes.Buffer 不应被视为终端")
+	}
 }
@@ -0,0 +1,76 @@
+package timeout
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/7836246/kanggo"
+	"github.com/7836246/kanggo/core"
+)
+
+// Config 是 timeout 中间件的配置结构体
+type Config struct {
+	Next    func(c *kanggo.Context) bool // 可选：跳过此中间件的函数
+	Timeout time.Duration                // 可选：请求的超时时间，默认值 10 秒
+	Error   error                        // 可选：超时后交给错误处理函数的错误，默认值 kanggo.ErrServiceUnavailable
+}
+
+// ConfigDefault 默认配置
+var ConfigDefault = Config{
+	Next:    nil,
+	Timeout: 10 * time.Second,
+	Error:   kanggo.ErrServiceUnavailable,
+}
+
+// configDefault 为未设置的配置项填充默认值
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+
+	if len(config) > 0 {
+		cfg = config[0]
+
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = ConfigDefault.Timeout
+		}
+
+		if cfg.Error == nil {
+			cfg.Error = ConfigDefault.Error
+		}
+	}
+
+	return cfg
+}
+
+// New 创建一个新的 timeout 中间件
+// 超时后请求的 context.Context 会被取消，并通过 Config.ErrorHandler 返回 cfg.Error；
+// 之后处理函数的写入会被丢弃，单个路由可以使用 kanggo.Timeout 设置不同的超时时间
+func New(config ...Config) core.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		// 后续的中间件与路由在独立的 goroutine 中使用独立的 Context 执行，超时后不会影响被回收的 Context
+		handler := kanggo.Timeout(func(ctx *kanggo.Context) error {
+			next(ctx.Writer, ctx.Request)
+			return nil
+		}, cfg.Timeout, cfg.Error)
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := kanggo.ContextOf(w)
+			if ctx == nil {
+				ctx = &kanggo.Context{}
+			}
+			ctx.Writer = w
+			ctx.Request = r
+
+			// 如果 Next 返回 true，则跳过此中间件
+			if cfg.Next != nil && cfg.Next(ctx) {
+				next(w, r)
+				return
+			}
+
+			if err := handler(ctx); err != nil {
+				ctx.Error(err)
+			}
+		}
+	}
+}
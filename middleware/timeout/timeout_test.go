@@ -0,0 +1,42 @@
+package timeout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/7836246/kanggo"
+)
+
+// 测试 timeout 中间件
+func TestTimeoutMiddleware(t *testing.T) {
+	cfg := kanggo.DefaultConfig()
+	cfg.ShowBanner = false
+	app := kanggo.New(cfg)
+	app.Use(New(Config{Timeout: 20 * time.Millisecond, Error: kanggo.ErrGatewayTimeout}))
+
+	app.GET("/slow", func(ctx *kanggo.Context) error {
+		select {
+		case <-ctx.Context().Done():
+			return ctx.Context().Err()
+		case <-time.After(time.Second):
+			return ctx.SendString("done")
+		}
+	})
+	app.GET("/fast", func(ctx *kanggo.Context) error {
+		return ctx.SendString("fast")
+	})
+
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if resp.Code != http.StatusGatewayTimeout {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusGatewayTimeout)
+	}
+
+	resp = httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if resp.Code != http.StatusOK || resp.Body.String() != "fast" {
+		t.Errorf("响应错误: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+	}
+}
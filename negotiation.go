@@ -0,0 +1,229 @@
+package kanggo
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// acceptSpec 表示 Accept 系列请求头中的一项，例如 "text/html;q=0.8"
+type acceptSpec struct {
+	value   string  // 媒体类型、编码、字符集或语言标签（已转为小写）
+	quality float64 // q 值，范围 0 ~ 1
+}
+
+// parseAccept 解析 Accept 系列请求头，返回其中的所有条目
+// 非法的 q 值按 0 处理，未指定 q 值时默认为 1
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		spec := acceptSpec{quality: 1}
+		fields := strings.Split(part, ";")
+		spec.value = strings.ToLower(strings.TrimSpace(fields[0]))
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(strings.ToLower(key)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			spec.quality = q
+		}
+		if spec.value != "" {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// negotiate 在 offers 中选出客户端最能接受的一项
+// match 返回请求头条目与候选项的匹配精确度，-1 表示不匹配；精确度越高的条目优先决定该候选项的 q 值
+// q 值相同时，按匹配精确度和 offers 中的先后顺序决定
+func negotiate(header string, offers []string, match func(spec, offer string) int) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	specs := parseAccept(header)
+	if len(specs) == 0 {
+		return offers[0]
+	}
+
+	best, bestQuality, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		quality, specificity := 0.0, -1
+		normalized := strings.ToLower(offer)
+		for _, spec := range specs {
+			if s := match(spec.value, normalized); s > specificity {
+				quality, specificity = spec.quality, s
+			}
+		}
+		if specificity < 0 || quality == 0 {
+			continue
+		}
+		if quality > bestQuality || (quality == bestQuality && specificity > bestSpecificity) {
+			best, bestQuality, bestSpecificity = offer, quality, specificity
+		}
+	}
+	return best
+}
+
+// matchMediaType 匹配媒体类型，支持 "*/*" 与 "type/*" 通配
+func matchMediaType(spec, offer string) int {
+	offer, _, _ = strings.Cut(offer, ";")
+	offer = strings.TrimSpace(offer)
+	switch {
+	case spec == offer:
+		return 2
+	case spec == "*/*" || spec == "*":
+		return 0
+	case strings.HasSuffix(spec, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(spec, "*")):
+		return 1
+	}
+	return -1
+}
+
+// matchToken 精确匹配编码或字符集，支持 "*" 通配
+func matchToken(spec, offer string) int {
+	switch spec {
+	case offer:
+		return 1
+	case "*":
+		return 0
+	}
+	return -1
+}
+
+// matchLanguage 按 RFC 4647 基本过滤规则匹配语言标签，例如 "en" 可以匹配 "en-US"
+func matchLanguage(spec, offer string) int {
+	switch {
+	case spec == offer:
+		return 2
+	case strings.HasPrefix(offer, spec+"-"):
+		return 1
+	case spec == "*":
+		return 0
+	}
+	return -1
+}
+
+// normalizeMediaType 将 "json"、".html" 等扩展名形式的候选项转换为完整的 MIME 类型
+func normalizeMediaType(offer string) string {
+	if strings.Contains(offer, "/") {
+		return offer
+	}
+	if typ := mime.TypeByExtension("." + strings.TrimPrefix(offer, ".")); typ != "" {
+		return typ
+	}
+	return offer
+}
+
+// Accepts 根据请求头 Accept 返回 offers 中最合适的内容类型，均不可接受时返回空字符串
+// offers 可以是完整的 MIME 类型，也可以是 "json"、"html" 这样的扩展名
+func (c *Context) Accepts(offers ...string) string {
+	normalized := make([]string, len(offers))
+	for i, offer := range offers {
+		normalized[i] = normalizeMediaType(offer)
+	}
+	best := negotiate(c.Request.Header.Get(constants.HeaderAccept), normalized, matchMediaType)
+	for i, offer := range normalized {
+		if offer == best && best != "" {
+			return offers[i]
+		}
+	}
+	return ""
+}
+
+// AcceptsEncodings 根据请求头 Accept-Encoding 返回 offers 中最合适的内容编码
+// 除非被 "identity;q=0" 或 "*;q=0" 显式拒绝，"identity" 始终可以接受，但优先级低于其他编码
+func (c *Context) AcceptsEncodings(offers ...string) string {
+	header := c.Request.Header.Get(constants.HeaderAcceptEncoding)
+	if header != "" {
+		implicitIdentity := true
+		for _, spec := range parseAccept(header) {
+			if spec.value == "identity" || spec.value == "*" {
+				implicitIdentity = false
+				break
+			}
+		}
+		if implicitIdentity {
+			header += ", identity;q=0.001"
+		}
+	}
+	return negotiate(header, offers, matchToken)
+}
+
+// AcceptsCharsets 根据请求头 Accept-Charset 返回 offers 中最合适的字符集
+func (c *Context) AcceptsCharsets(offers ...string) string {
+	return negotiate(c.Request.Header.Get(constants.HeaderAcceptCharset), offers, matchToken)
+}
+
+// AcceptsLanguages 根据请求头 Accept-Language 返回 offers 中最合适的语言
+func (c *Context) AcceptsLanguages(offers ...string) string {
+	return negotiate(c.Request.Header.Get(constants.HeaderAcceptLanguage), offers, matchLanguage)
+}
+
+// Format 根据请求头 Accept 选择 handlers 中对应的处理函数执行
+// handlers 的键为 MIME 类型或扩展名，键 "default" 用于没有可接受类型时的兜底处理
+// 既无匹配也无兜底时返回 406 Not Acceptable
+func (c *Context) Format(handlers map[string]func() error) error {
+	offers := make([]string, 0, len(handlers))
+	for key := range handlers {
+		if key != "default" {
+			offers = append(offers, key)
+		}
+	}
+	sort.Strings(offers) // 保证 q 值相同时的选择结果稳定
+
+	c.Writer.Header().Add(constants.HeaderVary, constants.HeaderAccept)
+	if best := c.Accepts(offers...); best != "" {
+		c.Writer.Header().Set(constants.HeaderContentType, normalizeMediaType(best))
+		return handlers[best]()
+	}
+	if fallback, ok := handlers["default"]; ok {
+		return fallback()
+	}
+	return c.SendError(http.StatusNotAcceptable, http.StatusText(http.StatusNotAcceptable))
+}
+
+// Negotiate 根据请求头 Accept 将同一份数据渲染为 JSON、XML、HTML 或纯文本
+// 只有传入 htmlName 且配置了模板引擎时才会参与 HTML 协商，此时使用名为 htmlName 的模板渲染 data
+func (c *Context) Negotiate(code int, data interface{}, htmlName ...string) error {
+	offers := []string{constants.MIMEApplicationJSON, constants.MIMEApplicationXML, constants.MIMETextXML}
+	if len(htmlName) > 0 && c.TemplateEngine != nil {
+		offers = append(offers, constants.MIMETextHTML)
+	}
+	offers = append(offers, constants.MIMETextPlain)
+
+	c.Writer.Header().Add(constants.HeaderVary, constants.HeaderAccept)
+	switch c.Accepts(offers...) {
+	case constants.MIMEApplicationJSON:
+		return c.JSON(code, data)
+	case constants.MIMEApplicationXML, constants.MIMETextXML:
+		return c.XML(code, data)
+	case constants.MIMETextHTML:
+		return c.Render(code, htmlName[0], data)
+	case constants.MIMETextPlain:
+		return c.send(code, constants.MIMETextPlainCharsetUTF8, []byte(fmt.Sprint(data)))
+	}
+	return c.SendError(http.StatusNotAcceptable, http.StatusText(http.StatusNotAcceptable))
+}
+
+// send 设置 Content-Type 和状态码后写入响应体
+func (c *Context) send(code int, contentType string, body []byte) error {
+	c.Writer.Header().Set(constants.HeaderContentType, contentType)
+	c.Writer.WriteHeader(code)
+	_, err := c.Writer.Write(body)
+	return err
+}
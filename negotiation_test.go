@@ -0,0 +1,135 @@
+package kanggo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newNegotiationContext 创建一个带有指定请求头的 Context，用于内容协商测试
+func newNegotiationContext(header, value string) (*Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp := httptest.NewRecorder()
+	return NewContext(resp, req, DefaultConfig()), resp
+}
+
+// 测试 Accepts 的 q 值与通配符处理
+func TestContextAccepts(t *testing.T) {
+	cases := []struct {
+		accept   string
+		offers   []string
+		expected string
+	}{
+		{"", []string{"application/json", "text/html"}, "application/json"},
+		{"text/html, application/json;q=0.9", []string{"application/json", "text/html"}, "text/html"},
+		{"text/*;q=0.5, application/json", []string{"text/plain", "application/json"}, "application/json"},
+		{"text/*, */*;q=0.1", []string{"application/xml", "text/plain"}, "text/plain"},
+		{"application/json;q=0", []string{"application/json"}, ""},
+		{"*/*;q=0.1, text/html;q=0", []string{"text/html", "image/png"}, "image/png"},
+		{"text/html", []string{"json", "html"}, "html"},
+		{"image/png", []string{"application/json"}, ""},
+	}
+
+	for _, tc := range cases {
+		ctx, _ := newNegotiationContext("Accept", tc.accept)
+		if got := ctx.Accepts(tc.offers...); got != tc.expected {
+			t.Errorf("Accept %q 协商错误: 得到 %q, 期待 %q", tc.accept, got, tc.expected)
+		}
+	}
+}
+
+// 测试 AcceptsEncodings、AcceptsCharsets 与 AcceptsLanguages
+func TestContextAcceptsVariants(t *testing.T) {
+	ctx, _ := newNegotiationContext("Accept-Encoding", "gzip;q=0.8, br")
+	if got := ctx.AcceptsEncodings("gzip", "br"); got != "br" {
+		t.Errorf("编码协商错误: 得到 %q, 期待 %q", got, "br")
+	}
+	if got := ctx.AcceptsEncodings("deflate", "identity"); got != "identity" {
+		t.Errorf("identity 应默认可接受: 得到 %q", got)
+	}
+
+	ctx, _ = newNegotiationContext("Accept-Encoding", "gzip, *;q=0")
+	if got := ctx.AcceptsEncodings("identity"); got != "" {
+		t.Errorf("identity 已被拒绝: 得到 %q", got)
+	}
+
+	ctx, _ = newNegotiationContext("Accept-Charset", "utf-8, iso-8859-1;q=0.5")
+	if got := ctx.AcceptsCharsets("iso-8859-1", "utf-8"); got != "utf-8" {
+		t.Errorf("字符集协商错误: 得到 %q, 期待 %q", got, "utf-8")
+	}
+
+	ctx, _ = newNegotiationContext("Accept-Language", "zh-CN, zh;q=0.9, en;q=0.8")
+	if got := ctx.AcceptsLanguages("en-US", "zh-TW"); got != "zh-TW" {
+		t.Errorf("语言协商错误: 得到 %q, 期待 %q", got, "zh-TW")
+	}
+	if got := ctx.AcceptsLanguages("fr"); got != "" {
+		t.Errorf("不可接受的语言: 得到 %q", got)
+	}
+}
+
+// 测试 Format 按 Accept 选择处理函数
+func TestContextFormat(t *testing.T) {
+	handlers := func(ctx *Context) map[string]func() error {
+		return map[string]func() error{
+			"application/json": func() error { return ctx.JSON(http.StatusOK, map[string]string{"a": "b"}) },
+			"text/plain":       func() error { return ctx.SendString("plain") },
+		}
+	}
+
+	ctx, resp := newNegotiationContext("Accept", "text/plain")
+	if err := ctx.Format(handlers(ctx)); err != nil {
+		t.Fatalf("Format 失败: %v", err)
+	}
+	if resp.Body.String() != "plain" {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), "plain")
+	}
+	if vary := resp.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Vary 头错误: 得到 %v, 期待 %v", vary, "Accept")
+	}
+
+	ctx, resp = newNegotiationContext("Accept", "image/png")
+	if err := ctx.Format(handlers(ctx)); err != nil {
+		t.Fatalf("Format 失败: %v", err)
+	}
+	if resp.Code != http.StatusNotAcceptable {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusNotAcceptable)
+	}
+}
+
+// 测试 Negotiate 将同一份数据渲染为不同格式
+func TestContextNegotiate(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	data := user{Name: "kanggo"}
+
+	cases := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"application/json", "application/json", `{"name":"kanggo"}`},
+		{"application/xml", "application/xml; charset=utf-8", "<user><name>kanggo</name></user>"},
+		{"text/plain", "text/plain; charset=utf-8", "{kanggo}"},
+	}
+
+	for _, tc := range cases {
+		ctx, resp := newNegotiationContext("Accept", tc.accept)
+		if err := ctx.Negotiate(http.StatusCreated, data); err != nil {
+			t.Fatalf("Negotiate 失败: %v", err)
+		}
+		if resp.Code != http.StatusCreated {
+			t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusCreated)
+		}
+		if ct := resp.Header().Get("Content-Type"); ct != tc.contentType {
+			t.Errorf("Content-Type 错误: 得到 %v, 期待 %v", ct, tc.contentType)
+		}
+		if body := strings.TrimSpace(resp.Body.String()); body != tc.body {
+			t.Errorf("响应内容错误: 得到 %v, 期待 %v", body, tc.body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package kanggo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsafeRedirect 表示重定向目标既不是相对地址，也不在 Config.RedirectAllowedHosts 中
+var ErrUnsafeRedirect = NewError(http.StatusBadRequest, "不安全的重定向地址")
+
+// Redirect 将请求重定向到 location，code 必须是 3xx 状态码
+// 为防止开放重定向，location 只能是相对地址、当前主机或 Config.RedirectAllowedHosts 中的主机，否则返回 ErrUnsafeRedirect
+func (c *Context) Redirect(code int, location string) error {
+	if code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect {
+		return fmt.Errorf("kanggo: 无效的重定向状态码 %d", code)
+	}
+	if !c.IsSafeRedirect(location) {
+		return ErrUnsafeRedirect
+	}
+	http.Redirect(c.Writer, c.Request, location, code)
+	return nil
+}
+
+// RedirectToRoute 重定向到已命名的路由，params 用于替换路由中的参数，默认状态码为 302
+func (c *Context) RedirectToRoute(name string, params map[string]string, code ...int) error {
+	if c.router == nil {
+		return errors.New("kanggo: Context 未关联路由器，无法按名称查找路由")
+	}
+	location, err := c.router.URL(name, params)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(redirectCode(code), location)
+}
+
+// RedirectBack 重定向回 Referer 指向的页面，Referer 缺失或不安全时重定向到 fallback，默认状态码为 302
+func (c *Context) RedirectBack(fallback string, code ...int) error {
+	location := c.Request.Referer()
+	if location == "" || !c.IsSafeRedirect(location) {
+		location = fallback
+	}
+	return c.Redirect(redirectCode(code), location)
+}
+
+// IsSafeRedirect 判断 location 是否可以安全地用作重定向目标
+func (c *Context) IsSafeRedirect(location string) bool {
+	if location == "" {
+		return false
+	}
+	// 浏览器会忽略制表符与换行符，并把反斜杠当作斜杠，"/\evil.com" 会被解析为 "//evil.com"
+	if strings.ContainsAny(location, "\\\t\r\n") {
+		return false
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return true // 相对地址
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return false // 例如 javascript:
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if c.Request != nil && host == strings.ToLower(hostWithoutPort(c.Request.Host)) {
+		return true
+	}
+	for _, pattern := range c.redirectHosts {
+		pattern = strings.ToLower(pattern)
+		if host == pattern || strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectCode 返回可选参数中的状态码，默认为 302
+func redirectCode(code []int) int {
+	if len(code) > 0 {
+		return code[0]
+	}
+	return http.StatusFound
+}
+
+// hostWithoutPort 去掉 Host 中的端口部分
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
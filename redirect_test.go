@@ -0,0 +1,142 @@
+package kanggo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 测试 Redirect 与开放重定向检查
+func TestContextRedirect(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RedirectAllowedHosts = []string{"*.kanggo.dev"}
+
+	cases := []struct {
+		location string
+		safe     bool
+	}{
+		{"/login", true},
+		{"profile?tab=1", true},
+		{"https://example.com/home", true}, // 当前主机
+		{"https://docs.kanggo.dev/start", true},
+		{"https://evil.com", false},
+		{"//evil.com", false},
+		{"/\\evil.com", false},
+		{"/\t/evil.com", false},
+		{"javascript:alert(1)", false},
+		{"https://kanggo.dev.evil.com", false},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+		resp := httptest.NewRecorder()
+		ctx := NewContext(resp, req, cfg)
+
+		err := ctx.Redirect(http.StatusSeeOther, tc.location)
+		if tc.safe {
+			if err != nil || resp.Code != http.StatusSeeOther {
+				t.Errorf("%q 应允许重定向: 状态码 %v, 错误 %v", tc.location, resp.Code, err)
+			}
+		} else if !errors.Is(err, ErrUnsafeRedirect) {
+			t.Errorf("%q 应返回 ErrUnsafeRedirect, 得到 %v", tc.location, err)
+		}
+	}
+
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), cfg)
+	if err := ctx.Redirect(http.StatusOK, "/"); err == nil {
+		t.Error("非 3xx 状态码应返回错误")
+	}
+}
+
+// 测试 RedirectBack 与 RedirectToRoute
+func TestRedirectBackAndRoute(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	router.Handle(http.MethodGet, "/users/:id/posts/:post", func(ctx *Context) error {
+		return nil
+	}).Name("user.post")
+	router.Handle(http.MethodGet, "/go", func(ctx *Context) error {
+		return ctx.RedirectToRoute("user.post", map[string]string{"id": "42", "post": "a b"})
+	})
+	router.Handle(http.MethodGet, "/back", func(ctx *Context) error {
+		return ctx.RedirectBack("/home")
+	})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/go", nil))
+	if location := resp.Header().Get("Location"); resp.Code != http.StatusFound || location != "/users/42/posts/a%20b" {
+		t.Errorf("RedirectToRoute 错误: 状态码 %v, Location %q", resp.Code, location)
+	}
+
+	if _, err := router.URL("user.post", map[string]string{"id": "1"}); err == nil {
+		t.Error("缺少参数时应返回错误")
+	}
+
+	referers := map[string]string{
+		"":                        "/home",
+		"/dashboard":              "/dashboard",
+		"https://evil.com/phish":  "/home",
+		"http://example.com/prev": "http://example.com/prev",
+	}
+	for referer, expected := range referers {
+		req := httptest.NewRequest(http.MethodGet, "/back", nil)
+		req.Header.Set("Referer", referer)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if location := resp.Header().Get("Location"); location != expected {
+			t.Errorf("Referer %q: Location 得到 %q, 期待 %q", referer, location, expected)
+		}
+	}
+}
+
+// 测试规范路径重定向
+func TestRedirectCanonicalPath(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RedirectCanonicalPath = true
+	router := NewRouter(cfg)
+	router.Handle(http.MethodGet, "/Docs/Intro", func(ctx *Context) error {
+		return ctx.SendString("intro")
+	})
+	router.Handle(http.MethodPost, "/submit", func(ctx *Context) error {
+		return nil
+	})
+	router.Handle(http.MethodGet, "/:lang/docs", func(ctx *Context) error {
+		return ctx.SendString("docs")
+	})
+
+	cases := []struct {
+		method, target string
+		code           int
+		location       string
+	}{
+		{http.MethodGet, "/docs/intro", http.StatusOK, ""},
+		{http.MethodGet, "/docs/intro/?page=2", http.StatusMovedPermanently, "/docs/intro?page=2"},
+		{http.MethodGet, "/DOCS/Intro", http.StatusMovedPermanently, "/docs/intro"},
+		{http.MethodPost, "/submit/", http.StatusPermanentRedirect, "/submit"},
+		{http.MethodGet, "/missing/", http.StatusNotFound, ""},
+		// 开头的多个斜杠必须合并，否则 Location 会指向其他站点
+		{http.MethodGet, "//Evil.com/DOCS", http.StatusMovedPermanently, "/evil.com/docs"},
+	}
+	for _, tc := range cases {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(tc.method, tc.target, nil))
+		if resp.Code != tc.code || resp.Header().Get("Location") != tc.location {
+			t.Errorf("%s %s: 状态码 %v, Location %q; 期待 %v, %q",
+				tc.method, tc.target, resp.Code, resp.Header().Get("Location"), tc.code, tc.location)
+		}
+	}
+
+	cfg.StrictRouting = true
+	cfg.CaseSensitiveRouting = true
+	router = NewRouter(cfg)
+	router.Handle(http.MethodGet, "/docs", func(ctx *Context) error { return nil })
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/Docs/", nil))
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("严格路由且区分大小写时不应重定向: 状态码 %v", resp.Code)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "//docs", nil))
+	if location := resp.Header().Get("Location"); location != "/docs" {
+		t.Errorf("开头的多个斜杠应合并: 状态码 %v, Location %q", resp.Code, location)
+	}
+}
@@ -0,0 +1,117 @@
+package kanggo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+
+	"github.com/7836246/kanggo/constants"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Renderer 将对象编码为某种 MIME 类型的响应体，签名与 Config.JSONEncoder 一致
+type Renderer func(v interface{}) ([]byte, error)
+
+// Decoder 将某种 MIME 类型的请求体解码到对象，签名与 Config.JSONDecoder 一致
+type Decoder func(data []byte, v interface{}) error
+
+// ErrNotProtoMessage 表示传给 Protobuf 编解码器的对象没有实现 proto.Message
+var ErrNotProtoMessage = errors.New("对象未实现 proto.Message 接口")
+
+// builtinRenderers 框架内置的响应渲染器，键为不带参数的 MIME 类型
+var builtinRenderers = map[string]Renderer{
+	constants.MIMEApplicationJSON:     json.Marshal,
+	constants.MIMEApplicationXML:      xml.Marshal,
+	constants.MIMETextXML:             xml.Marshal,
+	constants.MIMEApplicationYAML:     yaml.Marshal,
+	constants.MIMEApplicationXYAML:    yaml.Marshal,
+	constants.MIMETextYAML:            yaml.Marshal,
+	constants.MIMEApplicationMsgPack:  msgpack.Marshal,
+	constants.MIMEApplicationXMsgPack: msgpack.Marshal,
+	constants.MIMEApplicationProtobuf: marshalProtoBuf,
+}
+
+// builtinDecoders 框架内置的请求体解码器，键为不带参数的 MIME 类型
+var builtinDecoders = map[string]Decoder{
+	constants.MIMEApplicationJSON:     json.Unmarshal,
+	constants.MIMEApplicationXML:      xml.Unmarshal,
+	constants.MIMETextXML:             xml.Unmarshal,
+	constants.MIMEApplicationYAML:     yaml.Unmarshal,
+	constants.MIMEApplicationXYAML:    yaml.Unmarshal,
+	constants.MIMETextYAML:            yaml.Unmarshal,
+	constants.MIMEApplicationMsgPack:  msgpack.Unmarshal,
+	constants.MIMEApplicationXMsgPack: msgpack.Unmarshal,
+	constants.MIMEApplicationProtobuf: unmarshalProtoBuf,
+}
+
+// DefaultRenderers 返回内置响应渲染器的副本，可在此基础上增删后赋值给 Config.Renderers
+func DefaultRenderers() map[string]Renderer {
+	renderers := make(map[string]Renderer, len(builtinRenderers))
+	for mimeType, r := range builtinRenderers {
+		renderers[mimeType] = r
+	}
+	return renderers
+}
+
+// DefaultDecoders 返回内置请求体解码器的副本，可在此基础上增删后赋值给 Config.Decoders
+func DefaultDecoders() map[string]Decoder {
+	decoders := make(map[string]Decoder, len(builtinDecoders))
+	for mimeType, d := range builtinDecoders {
+		decoders[mimeType] = d
+	}
+	return decoders
+}
+
+// marshalProtoBuf 使用 proto.Marshal 编码实现了 proto.Message 的对象
+func marshalProtoBuf(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(msg)
+}
+
+// unmarshalProtoBuf 使用 proto.Unmarshal 解码到实现了 proto.Message 的对象
+func unmarshalProtoBuf(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// mediaType 去掉 Content-Type 中的参数部分，例如 "application/xml; charset=utf-8" => "application/xml"
+func mediaType(contentType string) string {
+	if typ, _, err := mime.ParseMediaType(contentType); err == nil {
+		return typ
+	}
+	return contentType
+}
+
+// renderer 查找指定 MIME 类型的渲染器，优先使用 Config.Renderers 中注册的实现
+func (c *Context) renderer(mimeType string) (Renderer, error) {
+	mimeType = mediaType(mimeType)
+	if r, ok := c.renderers[mimeType]; ok && r != nil {
+		return r, nil
+	}
+	if r, ok := builtinRenderers[mimeType]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("未注册 %s 类型的渲染器", mimeType)
+}
+
+// decoder 查找指定 MIME 类型的解码器，优先使用 Config.Decoders 中注册的实现
+func (c *Context) decoder(mimeType string) (Decoder, error) {
+	mimeType = mediaType(mimeType)
+	if d, ok := c.decoders[mimeType]; ok && d != nil {
+		return d, nil
+	}
+	if d, ok := builtinDecoders[mimeType]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("未注册 %s 类型的解码器", mimeType)
+}
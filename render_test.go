@@ -0,0 +1,135 @@
+package kanggo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// renderUser 用于渲染与绑定测试的数据结构
+type renderUser struct {
+	Name string `json:"name" xml:"name" yaml:"name" msgpack:"name"`
+	Age  int    `json:"age" xml:"age" yaml:"age" msgpack:"age"`
+}
+
+// 测试 XML、YAML、MessagePack 与 Protobuf 响应
+func TestContextRenderers(t *testing.T) {
+	user := renderUser{Name: "kanggo", Age: 3}
+	packed, _ := msgpack.Marshal(user)
+	message := wrapperspb.String("kanggo")
+	protoBytes, _ := proto.Marshal(message)
+
+	cases := []struct {
+		name        string
+		render      func(ctx *Context) error
+		contentType string
+		body        []byte
+	}{
+		{"XML", func(ctx *Context) error { return ctx.XML(http.StatusOK, user) },
+			"application/xml; charset=utf-8", []byte("<renderUser><name>kanggo</name><age>3</age></renderUser>")},
+		{"YAML", func(ctx *Context) error { return ctx.YAML(http.StatusOK, user) },
+			"application/yaml; charset=utf-8", []byte("name: kanggo\nage: 3\n")},
+		{"MsgPack", func(ctx *Context) error { return ctx.MsgPack(http.StatusOK, user) },
+			"application/vnd.msgpack", packed},
+		{"ProtoBuf", func(ctx *Context) error { return ctx.ProtoBuf(http.StatusOK, message) },
+			"application/x-protobuf", protoBytes},
+	}
+
+	for _, tc := range cases {
+		resp := httptest.NewRecorder()
+		ctx := NewContext(resp, httptest.NewRequest(http.MethodGet, "/", nil), DefaultConfig())
+		if err := tc.render(ctx); err != nil {
+			t.Fatalf("%s 渲染失败: %v", tc.name, err)
+		}
+		if ct := resp.Header().Get("Content-Type"); ct != tc.contentType {
+			t.Errorf("%s Content-Type 错误: 得到 %v, 期待 %v", tc.name, ct, tc.contentType)
+		}
+		if !bytes.Equal(resp.Body.Bytes(), tc.body) {
+			t.Errorf("%s 响应内容错误: 得到 %q, 期待 %q", tc.name, resp.Body.Bytes(), tc.body)
+		}
+	}
+
+	// 非 proto.Message 对象应返回错误
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), DefaultConfig())
+	if err := ctx.ProtoBuf(http.StatusOK, user); err != ErrNotProtoMessage {
+		t.Errorf("期待 ErrNotProtoMessage, 得到 %v", err)
+	}
+}
+
+// 测试通过 Config.Renderers 覆盖内置渲染器
+func TestCustomRenderer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Renderers["application/xml"] = func(v interface{}) ([]byte, error) {
+		return []byte("<custom/>"), nil
+	}
+
+	resp := httptest.NewRecorder()
+	ctx := NewContext(resp, httptest.NewRequest(http.MethodGet, "/", nil), cfg)
+	if err := ctx.XML(http.StatusOK, renderUser{}); err != nil {
+		t.Fatalf("XML 渲染失败: %v", err)
+	}
+	if resp.Body.String() != "<custom/>" {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), "<custom/>")
+	}
+
+	if err := ctx.Encode(http.StatusOK, "application/unknown", nil); err == nil {
+		t.Error("未注册的 MIME 类型应返回错误")
+	}
+}
+
+// 测试根据 Content-Type 绑定请求体
+func TestContextBind(t *testing.T) {
+	packed, _ := msgpack.Marshal(renderUser{Name: "kanggo", Age: 3})
+
+	cases := []struct {
+		contentType string
+		body        []byte
+	}{
+		{"application/json", []byte(`{"name":"kanggo","age":3}`)},
+		{"application/xml; charset=utf-8", []byte("<renderUser><name>kanggo</name><age>3</age></renderUser>")},
+		{"application/yaml", []byte("name: kanggo\nage: 3\n")},
+		{"application/x-msgpack", packed},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		ctx := NewContext(httptest.NewRecorder(), req, DefaultConfig())
+
+		var user renderUser
+		if err := ctx.Bind(&user); err != nil {
+			t.Fatalf("%s 绑定失败: %v", tc.contentType, err)
+		}
+		if user.Name != "kanggo" || user.Age != 3 {
+			t.Errorf("%s 绑定结果错误: 得到 %+v", tc.contentType, user)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data"))
+	req.Header.Set("Content-Type", "application/unknown")
+	ctx := NewContext(httptest.NewRecorder(), req, DefaultConfig())
+	if err := ctx.Bind(&renderUser{}); err == nil {
+		t.Error("未注册的 Content-Type 应返回错误")
+	}
+}
+
+// 测试 Protobuf 请求体绑定
+func TestContextBindProtoBuf(t *testing.T) {
+	body, _ := proto.Marshal(wrapperspb.String("kanggo"))
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	ctx := NewContext(httptest.NewRecorder(), req, DefaultConfig())
+
+	message := &wrapperspb.StringValue{}
+	if err := ctx.BindProtoBuf(message); err != nil {
+		t.Fatalf("Protobuf 绑定失败: %v", err)
+	}
+	if message.GetValue() != "kanggo" {
+		t.Errorf("绑定结果错误: 得到 %v, 期待 %v", message.GetValue(), "kanggo")
+	}
+}
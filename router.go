@@ -2,11 +2,13 @@ package kanggo
 
 import (
 	"fmt"
+	"github.com/7836246/kanggo/constants"
 	"github.com/7836246/kanggo/core"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 )
 
 // HandlerFunc 定义处理函数签名
@@ -43,6 +45,42 @@ type FileRouteInfo struct {
 	Handler HandlerFunc
 }
 
+// Route 表示一条已注册的路由
+type Route struct {
+	Method  string  // 请求方式
+	Pattern string  // 规范化后的路由模式
+	name    string  // 路由名称
+	router  *Router // 所属的路由器
+}
+
+// Name 为路由命名，之后可以通过 Router.URL 或 Context.RedirectToRoute 按名称生成地址
+func (rt *Route) Name(name string) *Route {
+	rt.name = name
+	rt.router.namedRoutes[name] = rt
+	return rt
+}
+
+// URL 使用 params 替换路由模式中的 :param 与 * 部分，生成请求地址
+func (rt *Route) URL(params map[string]string) (string, error) {
+	segments := strings.Split(rt.Pattern, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			value, ok := params[segment[1:]]
+			if !ok {
+				return "", fmt.Errorf("kanggo: 路由 %q 缺少参数 %q", rt.name, segment[1:])
+			}
+			segments[i] = url.PathEscape(value)
+		case segment == "*":
+			segments[i] = strings.TrimPrefix(params["*"], "/")
+		}
+	}
+	if path := strings.Join(segments, "/"); path != "" {
+		return path, nil
+	}
+	return "/", nil
+}
+
 // Router 路由结构
 type Router struct {
 	staticRoutes []StaticRouteInfo     // 普通静态路由列表
@@ -51,22 +89,61 @@ type Router struct {
 	routes       []RouteInfo           // 存储所有注册的动态路由信息
 	config       Config                // 添加配置到 Router 中
 	middleware   []core.MiddlewareFunc // 中间件切片
+	handler      http.HandlerFunc      // 预先构建好的中间件链，末端为 dispatch
+	contextPool  sync.Pool             // Context 对象池
+	serverHeader []string              // 预先构建的 Server 响应头值，避免每次请求分配
+	namedRoutes  map[string]*Route     // 已命名的路由
 }
 
-// Use 方法注册中间件到路由器
+// Use 方法注册中间件到路由器，并重新构建中间件链
 func (r *Router) Use(mw core.MiddlewareFunc) {
 	r.middleware = append(r.middleware, mw)
+	r.buildChain()
 }
 
 // NewRouter 创建一个新的路由器
 func NewRouter(cfg Config) *Router {
-	return &Router{
+	r := &Router{
 		staticRoutes: []StaticRouteInfo{}, // 初始化普通静态路由列表
 		fileRoutes:   []FileRouteInfo{},   // 初始化文件路由列表
 		dynamicRoot:  &RadixNode{children: make(map[string]*RadixNode)},
 		config:       cfg,
 		routes:       []RouteInfo{}, // 初始化路由信息列表
+		namedRoutes:  make(map[string]*Route),
+	}
+	if cfg.ServerHeader != "" {
+		r.serverHeader = []string{cfg.ServerHeader}
+	}
+	r.contextPool.New = func() interface{} {
+		ctx := &Context{}
+		ctx.init(r.config)
+		ctx.router = r
+		return ctx
 	}
+	r.buildChain()
+	return r
+}
+
+// buildChain 将所有中间件依次包装到 dispatch 外层，只在注册中间件时执行一次
+func (r *Router) buildChain() {
+	handler := http.HandlerFunc(r.dispatch)
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	r.handler = handler
+}
+
+// acquireContext 从对象池中取出一个 Context 并绑定到当前请求
+func (r *Router) acquireContext(w http.ResponseWriter, req *http.Request) *Context {
+	ctx := r.contextPool.Get().(*Context)
+	ctx.reset(w, req)
+	return ctx
+}
+
+// releaseContext 将 Context 放回对象池
+func (r *Router) releaseContext(ctx *Context) {
+	ctx.release()
+	r.contextPool.Put(ctx)
 }
 
 // RegisterStaticRoute 注册普通静态路由信息
@@ -129,8 +206,8 @@ func isFile(path string) bool {
 	return !info.IsDir()
 }
 
-// Handle 注册路由
-func (r *Router) Handle(method, pattern string, handler HandlerFunc) {
+// Handle 注册路由，返回的 *Route 可以通过 Name 命名
+func (r *Router) Handle(method, pattern string, handler HandlerFunc) *Route {
 	// 根据配置决定是否对路由进行大小写转换
 	if !r.config.CaseSensitiveRouting {
 		pattern = strings.ToLower(pattern)
@@ -164,6 +241,16 @@ func (r *Router) Handle(method, pattern string, handler HandlerFunc) {
 		// 记录动态路由信息
 		r.routes = append(r.routes, RouteInfo{Method: method, Pattern: pattern})
 	}
+	return &Route{Method: method, Pattern: pattern, router: r}
+}
+
+// URL 按名称查找路由并生成请求地址
+func (r *Router) URL(name string, params map[string]string) (string, error) {
+	route, ok := r.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("kanggo: 未找到名为 %q 的路由", name)
+	}
+	return route.URL(params)
 }
 
 // isStaticRoute 判断是否为普通静态路由（不包含 ":" 或 "*"）
@@ -211,8 +298,8 @@ func (r *Router) insertDynamicRoute(method, pattern string, handler HandlerFunc)
 // ServeHTTP 实现 http.Handler 接口
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// 设置响应头中的 Server 字段
-	if r.config.ServerHeader != "" {
-		w.Header().Set("Server", r.config.ServerHeader)
+	if r.serverHeader != nil {
+		w.Header()["Server"] = r.serverHeader
 	}
 
 	// 检查请求体大小是否超过配置的最大限制
@@ -221,91 +308,221 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// 根据配置决定是否对路径进行解码
-	path := req.URL.Path
-	if r.config.UnescapePath {
-		unescapedPath, err := url.PathUnescape(path)
+	path, err := r.requestPath(req)
+	if err != nil {
+		http.Error(w, "路径解码错误", http.StatusBadRequest)
+		return
+	}
+
+	// 从对象池中取出 Context，经过中间件链后由 dispatch 找回
+	ctx := r.acquireContext(w, req)
+	defer r.releaseContext(ctx)
+	ctx.path = path
+
+	r.handler(&ctx.writer, req)
+}
+
+// requestPath 返回用于匹配路由的请求路径，开启 UnescapePath 时先进行解码
+func (r *Router) requestPath(req *http.Request) (string, error) {
+	if !r.config.UnescapePath {
+		return req.URL.Path, nil
+	}
+	return url.PathUnescape(req.URL.Path)
+}
+
+// dispatch 是中间件链的末端，负责查找路由并执行处理函数
+func (r *Router) dispatch(w http.ResponseWriter, req *http.Request) {
+	ctx := contextFromWriter(w)
+	if ctx == nil {
+		// 中间件包装的 ResponseWriter 没有实现 Unwrap，无法找回原 Context，只能重新创建
+		path, err := r.requestPath(req)
 		if err != nil {
 			http.Error(w, "路径解码错误", http.StatusBadRequest)
 			return
 		}
-		path = unescapedPath
+		ctx = r.acquireContext(w, req)
+		defer r.releaseContext(ctx)
+		ctx.path = path
+	}
+
+	// 中间件可能包装了 ResponseWriter 或替换了请求，Context 需要使用最终传入的版本
+	ctx.Writer = w
+	ctx.Request = req
+	if ctx.locals != nil {
+		ctx.bindLocals() // 中间件替换请求后，重新让 context.Context 携带局部变量
 	}
+	path := ctx.path
 
-	// 创建 Context 时传递配置参数
-	ctx := NewContext(w, req, r.config)
+	if r.serve(ctx, path) {
+		return
+	}
 
-	// 最终的处理函数，实际处理请求逻辑
-	finalHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		// 查找文件路由
-		for _, fileRoute := range r.fileRoutes {
-			if path == fileRoute.Prefix || strings.HasPrefix(path, fileRoute.Prefix+"/") {
-				req.URL.Path = strings.TrimPrefix(path, fileRoute.Prefix)
-				if err := fileRoute.Handler(ctx); err != nil {
-					r.handleError(w, err)
-				}
+	// 未匹配到路由时，尝试重定向到规范路径
+	if r.config.RedirectCanonicalPath {
+		if canonical := r.canonicalPath(path); canonical != path && r.hasRoute(ctx, canonical) {
+			if r.redirectCanonical(ctx, canonical) {
 				return
 			}
 		}
+	}
 
-		// 查找静态路由
-		for _, staticRoute := range r.staticRoutes {
-			if path == staticRoute.Prefix {
-				if err := staticRoute.Handler(ctx); err != nil {
-					r.handleError(w, err)
-				}
-				return
+	// 如果没有匹配的路由，返回 404
+	http.NotFound(w, req)
+}
+
+// serve 查找与 path 匹配的路由并执行处理函数，未匹配时返回 false
+func (r *Router) serve(ctx *Context, path string) bool {
+	req := ctx.Request
+
+	// 查找静态路由
+	for _, staticRoute := range r.staticRoutes {
+		if path == staticRoute.Prefix {
+			if err := staticRoute.Handler(ctx); err != nil {
+				r.handleError(ctx, err)
 			}
+			return true
 		}
+	}
 
-		// 查找动态路由
-		if handler, found := r.searchDynamicRoute(req.Method, path, ctx); found {
-			if err := handler(ctx); err != nil {
-				r.handleError(w, err)
-			}
-			return
+	// 查找挂载在前缀下的文件路由，例如 /static，优先于动态路由
+	if fileRoute := r.matchFileRoute(path, false); fileRoute != nil {
+		return r.serveFile(ctx, fileRoute, path)
+	}
+
+	// 查找动态路由
+	if handler, found := r.searchDynamicRoute(req.Method, path, ctx); found {
+		if err := handler(ctx); err != nil {
+			r.handleError(ctx, err)
 		}
+		return true
+	}
+	ctx.Params = ctx.Params[:0] // 清除匹配失败时残留的参数
 
-		// 如果没有匹配的路由，返回 404
-		http.NotFound(w, req)
-	})
+	// 最后查找挂载在根路径的文件路由，它匹配所有路径，不能遮挡动态路由
+	if fileRoute := r.matchFileRoute(path, true); fileRoute != nil {
+		return r.serveFile(ctx, fileRoute, path)
+	}
+	return false
+}
 
-	// 应用所有中间件，注意类型转换
-	wrappedHandler := finalHandler
-	for i := len(r.middleware) - 1; i >= 0; i-- {
-		wrappedHandler = r.middleware[i](wrappedHandler)
+// serveFile 去掉挂载前缀后执行文件路由的处理函数
+func (r *Router) serveFile(ctx *Context, fileRoute *FileRouteInfo, path string) bool {
+	ctx.Request.URL.Path = strings.TrimPrefix(path, fileRoute.Prefix)
+	if err := fileRoute.Handler(ctx); err != nil {
+		r.handleError(ctx, err)
+	}
+	return true
+}
+
+// matchFileRoute 查找与 path 匹配的文件路由，root 为 true 时只查找挂载在根路径的路由，否则只查找挂载在前缀下的路由
+func (r *Router) matchFileRoute(path string, root bool) *FileRouteInfo {
+	for i := range r.fileRoutes {
+		fileRoute := &r.fileRoutes[i]
+		if isRootPrefix(fileRoute.Prefix) != root {
+			continue
+		}
+		if root || hasPathPrefix(path, fileRoute.Prefix) {
+			return fileRoute
+		}
+	}
+	return nil
+}
+
+// isRootPrefix 判断文件路由是否挂载在根路径
+func isRootPrefix(prefix string) bool {
+	return prefix == "" || prefix == "/"
+}
+
+// hasRoute 判断 path 是否能匹配到路由，不执行处理函数
+func (r *Router) hasRoute(ctx *Context, path string) bool {
+	for _, staticRoute := range r.staticRoutes {
+		if path == staticRoute.Prefix {
+			return true
+		}
+	}
+	if r.matchFileRoute(path, false) != nil {
+		return true
+	}
+	_, found := r.searchDynamicRoute(ctx.Request.Method, path, ctx)
+	ctx.Params = ctx.Params[:0]
+	return found || r.matchFileRoute(path, true) != nil
+}
+
+// canonicalPath 按路由配置返回规范路径：开头连续的斜杠合并为一个，非严格路由去掉末尾斜杠，不区分大小写时转为小写
+// 路由匹配会跳过空的路径段，但 "//evil.com/docs" 作为 Location 会被浏览器当作其他站点的地址，因此必须合并
+func (r *Router) canonicalPath(path string) string {
+	if strings.HasPrefix(path, "//") {
+		path = "/" + strings.TrimLeft(path, "/")
 	}
+	if !r.config.StrictRouting && len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+	if !r.config.CaseSensitiveRouting {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// redirectCanonical 将请求永久重定向到规范路径并保留查询参数
+// GET 与 HEAD 使用 301，其他请求方式使用 308 以保留请求方式与请求体
+// 与 Redirect 一样只允许安全的地址，不安全时不重定向并返回 false
+func (r *Router) redirectCanonical(ctx *Context, path string) bool {
+	code := http.StatusPermanentRedirect
+	if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	if ctx.Request.URL.RawQuery != "" {
+		path += "?" + ctx.Request.URL.RawQuery
+	}
+	if !ctx.IsSafeRedirect(path) {
+		return false
+	}
+	ctx.Writer.Header().Set(constants.HeaderLocation, path)
+	ctx.Writer.WriteHeader(code)
+	return true
+}
 
-	// 处理最终的请求
-	wrappedHandler.ServeHTTP(w, req)
+// hasPathPrefix 判断 path 是否等于 prefix 或位于 prefix 目录之下，不产生字符串拼接
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
 }
 
 // searchDynamicRoute 在 Radix Tree 中查找动态路由
+// 逐段扫描路径而不是使用 strings.Split，匹配过程中不分配内存
 func (r *Router) searchDynamicRoute(method, path string, ctx *Context) (HandlerFunc, bool) {
-	parts := strings.Split(path, "/")
 	node := r.dynamicRoot
-	var child *RadixNode // 在循环外声明 child 变量
-	var ok bool          // 在循环外声明 ok 变量
-
-	for _, part := range parts {
-		if part == "" {
+	for start := 0; start < len(path); {
+		if path[start] == '/' {
+			start++
 			continue
 		}
+		end := strings.IndexByte(path[start:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += start
+		}
+		part := path[start:end]
+		start = end
 
 		// 先尝试静态部分匹配
-		child, ok = node.children[part]
-		if ok {
+		if child, ok := node.children[part]; ok {
 			node = child
-		} else {
-			// 再尝试参数化部分匹配
-			child, ok = node.children[":param"]
-			if ok {
-				ctx.Params[child.paramKey] = part
-				node = child
-			} else {
-				return nil, false
-			}
+			continue
 		}
+		// 再尝试参数化部分匹配
+		child, ok := node.children[":param"]
+		if !ok {
+			return nil, false
+		}
+		ctx.Params = append(ctx.Params, Param{Key: child.paramKey, Value: part})
+		node = child
 	}
 
 	if node.isLeaf {
@@ -314,7 +531,7 @@ func (r *Router) searchDynamicRoute(method, path string, ctx *Context) (HandlerF
 	return nil, false
 }
 
-// handleError 统一的错误处理
-func (r *Router) handleError(w http.ResponseWriter, err error) {
-	http.Error(w, err.Error(), http.StatusInternalServerError)
+// handleError 统一的错误处理，交给 Config.ErrorHandler 生成响应
+func (r *Router) handleError(ctx *Context, err error) {
+	ctx.Error(err)
 }
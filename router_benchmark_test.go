@@ -0,0 +1,115 @@
+package kanggo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// benchmarkWriter 是不分配内存的 ResponseWriter，用于衡量路由本身的开销
+type benchmarkWriter struct {
+	header http.Header
+}
+
+func (w *benchmarkWriter) Header() http.Header         { return w.header }
+func (w *benchmarkWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *benchmarkWriter) WriteHeader(int)             {}
+
+// newBenchmarkRouter 创建带有静态路由、动态路由和一个中间件的路由器
+func newBenchmarkRouter() *Router {
+	router := NewRouter(DefaultConfig())
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r)
+		}
+	})
+	noop := func(ctx *Context) error { return nil }
+	router.Handle("GET", "/", noop)
+	router.Handle("GET", "/home", noop)
+	router.Handle("GET", "/api/users", noop)
+	router.Handle("GET", "/api/users/:id/posts/:postId", func(ctx *Context) error {
+		_ = ctx.Param("postId")
+		return nil
+	})
+	return router
+}
+
+// 测试静态与动态路由在对象池预热后不再分配内存
+func TestRoutingZeroAlloc(t *testing.T) {
+	router := newBenchmarkRouter()
+	w := &benchmarkWriter{header: make(http.Header)}
+
+	for _, path := range []string{"/api/users", "/api/users/42/posts/7"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req) // 预热对象池
+
+		allocs := testing.AllocsPerRun(100, func() {
+			router.ServeHTTP(w, req)
+		})
+		if allocs != 0 {
+			t.Errorf("路由 %s 每次请求分配了 %v 次内存, 期待 0", path, allocs)
+		}
+	}
+}
+
+// 测试 Context 被回收后不会残留上一个请求的路径参数
+func TestContextPoolReset(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	router.Handle("GET", "/user/:id", func(ctx *Context) error {
+		return ctx.SendString(ctx.Param("id") + "," + ctx.Param("name"))
+	})
+	router.Handle("GET", "/named/:name", func(ctx *Context) error {
+		return ctx.SendString(ctx.Param("id") + "," + ctx.Param("name"))
+	})
+
+	for _, tc := range []struct{ path, expected string }{
+		{"/user/1", "1,"},
+		{"/named/kanggo", ",kanggo"},
+		{"/user/2", "2,"},
+	} {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if resp.Body.String() != tc.expected {
+			t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), tc.expected)
+		}
+	}
+}
+
+// BenchmarkStaticRoute 衡量静态路由的开销
+func BenchmarkStaticRoute(b *testing.B) {
+	router := newBenchmarkRouter()
+	w := &benchmarkWriter{header: make(http.Header)}
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
+// BenchmarkDynamicRoute 衡量带两个路径参数的动态路由的开销
+func BenchmarkDynamicRoute(b *testing.B) {
+	router := newBenchmarkRouter()
+	w := &benchmarkWriter{header: make(http.Header)}
+	req := httptest.NewRequest(http.MethodGet, "/api/users/42/posts/7", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
+// BenchmarkParallelRouting 衡量并发场景下对象池的效果
+func BenchmarkParallelRouting(b *testing.B) {
+	router := newBenchmarkRouter()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		w := &benchmarkWriter{header: make(http.Header)}
+		req := httptest.NewRequest(http.MethodGet, "/api/users/42/posts/7", nil)
+		for pb.Next() {
+			router.ServeHTTP(w, req)
+		}
+	})
+}
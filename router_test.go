@@ -97,6 +97,28 @@ func TestDynamicRoute(t *testing.T) {
 	}
 }
 
+// 测试中间件包装的 ResponseWriter 无法找回原 Context 时，重新创建的 Context 同样按配置解码路径
+func TestDispatchWrappedWriter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.UnescapePath = true
+	router := NewRouter(cfg)
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(struct{ http.ResponseWriter }{w}, r) // 没有实现 Unwrap
+		}
+	})
+	router.Handle(http.MethodGet, "/files/:name", func(ctx *Context) error {
+		params := ctx.Params.Map()
+		return ctx.SendString(params["name"])
+	})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/files/a%2520b", nil))
+	if resp.Code != http.StatusOK || resp.Body.String() != "a b" {
+		t.Errorf("响应错误: 状态码 %v, 内容 %q; 期待 %v, %q", resp.Code, resp.Body.String(), http.StatusOK, "a b")
+	}
+}
+
 // 测试 Add 方法的注册和处理
 func TestAddMethod(t *testing.T) {
 	router := NewRouter(DefaultConfig())
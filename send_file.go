@@ -0,0 +1,171 @@
+package kanggo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// SendFile 发送文件作为响应，download 为 true 时以附件形式下载
+// Content-Type 先按扩展名识别，无法识别时根据文件内容嗅探；
+// 支持 Range（包括多段范围）、If-Modified-Since、If-None-Match、If-Range 等条件请求
+// 文件不存在时返回 ErrNotFound，由错误处理函数生成响应
+func (c *Context) SendFile(filepath string, download bool) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return fileError(err)
+	}
+	return c.serveFile(file, sendFileOptions{download: download})
+}
+
+// SendFileFS 从 fs.FS（例如 embed.FS）中发送文件，行为与 SendFile 相同
+// name 会被清理为 fs.FS 接受的相对路径，无法通过 ".." 访问 fsys 之外的文件
+func (c *Context) SendFileFS(fsys fs.FS, name string, download bool) error {
+	file, err := fsys.Open(cleanFSPath(name))
+	if err != nil {
+		return fileError(err)
+	}
+	return c.serveFile(file, sendFileOptions{download: download})
+}
+
+// sendFileOptions 控制文件的发送方式
+type sendFileOptions struct {
+	download bool   // 是否以附件形式下载
+	noRange  bool   // 是否禁用范围请求
+	encoding string // 内容已经使用的 Content-Encoding，例如预压缩的 gzip 文件
+}
+
+// serveFile 发送已打开的文件，发送完毕后关闭文件
+func (c *Context) serveFile(file fs.File, opts sendFileOptions) error {
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return ErrNotFound
+	}
+
+	// http.ServeContent 需要 io.ReadSeeker 来处理范围请求与内容嗅探
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := fileETag(info, content)
+	if err != nil {
+		return err
+	}
+	return c.serveContent(info.Name(), info.ModTime(), etag, content, opts)
+}
+
+// serveContent 通过 http.ServeContent 发送内容，处理 Content-Disposition、ETag、Content-Encoding 与范围请求开关
+func (c *Context) serveContent(name string, modTime time.Time, etag string, content io.ReadSeeker, opts sendFileOptions) error {
+	header := c.Writer.Header()
+	if opts.download {
+		header.Set(constants.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	if opts.encoding != "" {
+		header.Set(constants.HeaderContentEncoding, opts.encoding)
+		etag = etagWithSuffix(etag, opts.encoding) // 不同编码的表示需要不同的 ETag
+	}
+	if header.Get(constants.HeaderETag) == "" {
+		header.Set(constants.HeaderETag, etag)
+	}
+
+	w, req := c.Writer, c.Request
+	if opts.noRange {
+		// 在副本上移除范围请求头，之后的中间件与日志看到的仍是原始请求
+		w = &noRangeWriter{ResponseWriter: w}
+		req = req.Clone(req.Context())
+		req.Header.Del(constants.HeaderRange)
+		req.Header.Del(constants.HeaderIfRange)
+	}
+	http.ServeContent(w, req, name, modTime, content)
+	return nil
+}
+
+// noRangeWriter 在发送响应头前移除 http.ServeContent 自动添加的 Accept-Ranges
+type noRangeWriter struct {
+	http.ResponseWriter
+}
+
+// WriteHeader 声明不支持范围请求后发送响应头
+func (w *noRangeWriter) WriteHeader(code int) {
+	w.Header().Set(constants.HeaderAcceptRanges, "none")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// ReadFrom 转发给底层的 io.ReaderFrom，保留 sendfile 等零拷贝发送方式
+func (w *noRangeWriter) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r) // 隐藏 ReadFrom，防止 io.Copy 递归调用
+}
+
+// Unwrap 返回被包装的 ResponseWriter
+func (w *noRangeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// etagWithSuffix 在 ETag 的引号内追加后缀，例如 W/"abc" 变为 W/"abc-gzip"
+func etagWithSuffix(etag, suffix string) string {
+	if strings.HasSuffix(etag, `"`) {
+		return etag[:len(etag)-1] + "-" + suffix + `"`
+	}
+	return etag
+}
+
+// fileETag 为文件生成 ETag
+// 有修改时间时使用大小与修改时间生成弱 ETag；embed.FS 等没有修改时间的文件根据内容的哈希生成强 ETag
+func fileETag(info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()), nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, hash.Sum(nil)[:16]), nil
+}
+
+// cleanFSPath 将请求路径转换为 fs.FS 可以接受的相对路径
+func cleanFSPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// fileError 将打开文件时的错误转换为对应状态码的错误
+func fileError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		return ErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrForbidden
+	default:
+		return err
+	}
+}
@@ -0,0 +1,143 @@
+package kanggo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// 测试 SendFile 的 Content-Type 识别、下载与条件请求
+func TestSendFile(t *testing.T) {
+	dir := t.TempDir()
+	cssPath := filepath.Join(dir, "style.css")
+	noExtPath := filepath.Join(dir, "README")
+	if err := os.WriteFile(cssPath, []byte("body { color: red; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(noExtPath, []byte("<!DOCTYPE html><html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(req *http.Request, path string, download bool) (*httptest.ResponseRecorder, error) {
+		resp := httptest.NewRecorder()
+		err := NewContext(resp, req, DefaultConfig()).SendFile(path, download)
+		return resp, err
+	}
+
+	resp, err := send(httptest.NewRequest(http.MethodGet, "/", nil), cssPath, false)
+	if err != nil {
+		t.Fatalf("发送文件失败: %v", err)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "text/css; charset=utf-8" {
+		t.Errorf("按扩展名识别 Content-Type 错误: 得到 %v", ct)
+	}
+	etag := resp.Header().Get("ETag")
+	lastModified := resp.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Errorf("缺少 ETag 或 Last-Modified: %q, %q", etag, lastModified)
+	}
+
+	resp, _ = send(httptest.NewRequest(http.MethodGet, "/", nil), noExtPath, true)
+	if ct := resp.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("根据内容嗅探 Content-Type 错误: 得到 %v", ct)
+	}
+	if cd := resp.Header().Get("Content-Disposition"); cd != `attachment; filename=README` {
+		t.Errorf("Content-Disposition 错误: 得到 %v", cd)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	if resp, _ = send(req, cssPath, false); resp.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match 状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusNotModified)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if resp, _ = send(req, cssPath, false); resp.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since 状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusNotModified)
+	}
+
+	if _, err = send(httptest.NewRequest(http.MethodGet, "/", nil), filepath.Join(dir, "missing"), false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("文件不存在时应返回 ErrNotFound, 得到 %v", err)
+	}
+}
+
+// 测试 SendFileFS 的范围请求与路径清理
+func TestSendFileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"assets/data.txt": {Data: []byte("0123456789")},
+	}
+	send := func(req *http.Request, name string) (*httptest.ResponseRecorder, error) {
+		resp := httptest.NewRecorder()
+		err := NewContext(resp, req, DefaultConfig()).SendFileFS(fsys, name, false)
+		return resp, err
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	resp, err := send(req, "/assets/data.txt")
+	if err != nil {
+		t.Fatalf("发送文件失败: %v", err)
+	}
+	if resp.Code != http.StatusPartialContent || resp.Body.String() != "2345" {
+		t.Errorf("范围请求错误: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+	}
+	if cr := resp.Header().Get("Content-Range"); cr != "bytes 2-5/10" {
+		t.Errorf("Content-Range 错误: 得到 %v", cr)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=0-1,8-9")
+	resp, _ = send(req, "assets/data.txt")
+	if !strings.HasPrefix(resp.Header().Get("Content-Type"), "multipart/byteranges") {
+		t.Errorf("多段范围请求 Content-Type 错误: 得到 %v", resp.Header().Get("Content-Type"))
+	}
+
+	// 没有修改时间的文件使用内容哈希作为 ETag
+	resp, _ = send(httptest.NewRequest(http.MethodGet, "/", nil), "assets/data.txt")
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", resp.Header().Get("ETag"))
+	if resp, _ = send(req, "assets/data.txt"); resp.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match 状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusNotModified)
+	}
+
+	for _, name := range []string{"../assets/data.txt/../../secret", "assets", "missing.txt"} {
+		if _, err := send(httptest.NewRequest(http.MethodGet, "/", nil), name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%q 应返回 ErrNotFound, 得到 %v", name, err)
+		}
+	}
+}
+
+// readFromRecorder 记录 ReadFrom 是否被调用
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+// 测试禁用范围请求时仍然把 ReadFrom 转发给底层的 ResponseWriter，http.ServeContent 使用 io.CopyN 发送内容
+func TestNoRangeWriterReadFrom(t *testing.T) {
+	rec := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w := &noRangeWriter{ResponseWriter: rec}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.CopyN(w, strings.NewReader("0123456789"), 10); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if !rec.readFrom || rec.Body.String() != "0123456789" {
+		t.Errorf("ReadFrom 未被转发: readFrom=%v, 内容 %q", rec.readFrom, rec.Body.String())
+	}
+	if ar := rec.Header().Get("Accept-Ranges"); ar != "none" {
+		t.Errorf("Accept-Ranges 错误: 得到 %q", ar)
+	}
+}
@@ -0,0 +1,179 @@
+package kanggo
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// SSEvent 表示一条 Server-Sent Events 事件
+type SSEvent struct {
+	ID    string        // 事件 ID，客户端重连时会通过 Last-Event-ID 请求头带回
+	Event string        // 事件类型，为空时客户端按 "message" 处理
+	Data  interface{}   // 事件数据，string 与 []byte 原样发送，其他类型编码为 JSON
+	Retry time.Duration // 建议客户端断线后的重连间隔，为 0 时不发送
+}
+
+// EventStream 是 Server-Sent Events 的事件流写入器，可以在多个 goroutine 中并发使用
+type EventStream struct {
+	ctx    *Context
+	encode func(v interface{}) ([]byte, error)
+	mu     sync.Mutex
+}
+
+// SSE 将当前响应切换为 text/event-stream 并返回事件流写入器
+// 响应头会立即发送给客户端，之后不应再调用其他响应方法
+func (c *Context) SSE() *EventStream {
+	header := c.Writer.Header()
+	header.Set(constants.HeaderContentType, constants.MIMETextEventStream)
+	header.Set(constants.HeaderCacheControl, "no-cache")
+	header.Set(constants.HeaderConnection, "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 禁止 Nginx 等反向代理缓冲事件
+	header.Del(constants.HeaderContentLength)
+	c.Writer.WriteHeader(constants.StatusOK)
+	_ = c.Flush()
+
+	encode := c.jsonEncoder
+	if encode == nil {
+		encode = json.Marshal
+	}
+	return &EventStream{ctx: c, encode: encode}
+}
+
+// LastEventID 返回客户端断线重连时携带的 Last-Event-ID，可据此补发遗漏的事件
+func (s *EventStream) LastEventID() string {
+	return s.ctx.Request.Header.Get(constants.HeaderLastEventID)
+}
+
+// Done 返回一个在客户端断开连接时关闭的通道
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Request.Context().Done()
+}
+
+// Send 发送一条完整的事件并立即刷新，客户端已断开时返回其原因
+func (s *EventStream) Send(event SSEvent) error {
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + sanitizeSSEField(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + sanitizeSSEField(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	if event.Data != nil {
+		data, err := s.marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		// 多行数据需要拆分为多个 data 字段，客户端会用换行符重新拼接
+		for _, line := range splitSSELines(data) {
+			b.WriteString("data: " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Event 发送一条指定类型的事件
+func (s *EventStream) Event(name string, data interface{}) error {
+	return s.Send(SSEvent{Event: name, Data: data})
+}
+
+// Data 发送一条默认类型（message）的事件
+func (s *EventStream) Data(data interface{}) error {
+	return s.Send(SSEvent{Data: data})
+}
+
+// Retry 单独发送重连间隔，通知客户端断线后等待多久再重连
+func (s *EventStream) Retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// Comment 发送一条注释行，客户端会忽略注释，常用于保持连接活跃
+func (s *EventStream) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range splitSSELines(text) {
+		b.WriteString(": " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Heartbeat 在后台每隔 interval 发送一次注释行，防止连接被代理服务器判定为空闲而关闭
+// 客户端断开时心跳自动停止；处理函数返回前必须调用返回的 stop 函数，stop 返回后不会再有写入
+func (s *EventStream) Heartbeat(interval time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-s.Done():
+				return
+			case <-ticker.C:
+				if s.Comment("heartbeat") != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(quit)
+			<-finished
+		})
+	}
+}
+
+// write 写入一段事件文本并刷新，客户端已断开时返回其原因
+func (s *EventStream) write(text string) error {
+	if err := s.ctx.Request.Context().Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.ctx.Writer.Write([]byte(text)); err != nil {
+		return err
+	}
+	return s.ctx.Flush()
+}
+
+// marshal 将事件数据转换为文本
+func (s *EventStream) marshal(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		out, err := s.encode(v)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+}
+
+// splitSSELines 按 "\r\n"、"\r" 与 "\n" 拆分多行文本，三者都是事件流的行结束符，
+// 只按 "\n" 拆分时单独的 "\r" 会被客户端当作换行，从而注入 event、id 等字段
+func splitSSELines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n")
+}
+
+// sanitizeSSEField 去掉单行字段中的换行符，防止注入额外的字段
+func sanitizeSSEField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,112 @@
+package kanggo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncRecorder 是并发安全的 ResponseRecorder，用于心跳测试
+type syncRecorder struct {
+	*httptest.ResponseRecorder
+	mu sync.Mutex
+}
+
+func (r *syncRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Write(p)
+}
+
+func (r *syncRecorder) body() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Body.String()
+}
+
+// 测试 SSE 响应头与事件格式
+func TestContextSSE(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	resp := httptest.NewRecorder()
+	ctx := NewContext(resp, req, DefaultConfig())
+
+	stream := ctx.SSE()
+	if stream.LastEventID() != "41" {
+		t.Errorf("Last-Event-ID 错误: 得到 %v, 期待 %v", stream.LastEventID(), "41")
+	}
+	if err := stream.Send(SSEvent{ID: "42", Event: "update", Data: "line1\nline2", Retry: 3 * time.Second}); err != nil {
+		t.Fatalf("发送事件失败: %v", err)
+	}
+	if err := stream.Data(map[string]int{"count": 1}); err != nil {
+		t.Fatalf("发送事件失败: %v", err)
+	}
+	if err := stream.Comment("ping"); err != nil {
+		t.Fatalf("发送注释失败: %v", err)
+	}
+	if err := stream.Event("bad\nname", "x"); err != nil {
+		t.Fatalf("发送事件失败: %v", err)
+	}
+	// 单独的 "\r" 也是行结束符，不能借此注入 event 字段
+	if err := stream.Data("a\revent: x\r\nb"); err != nil {
+		t.Fatalf("发送事件失败: %v", err)
+	}
+	if err := stream.Comment("c\rid: 1"); err != nil {
+		t.Fatalf("发送注释失败: %v", err)
+	}
+
+	if ct := resp.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type 错误: 得到 %v, 期待 %v", ct, "text/event-stream")
+	}
+	if cc := resp.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control 错误: 得到 %v, 期待 %v", cc, "no-cache")
+	}
+
+	expected := "id: 42\nevent: update\nretry: 3000\ndata: line1\ndata: line2\n\n" +
+		"data: {\"count\":1}\n\n" +
+		": ping\n\n" +
+		"event: badname\ndata: x\n\n" +
+		"data: a\ndata: event: x\ndata: b\n\n" +
+		": c\n: id: 1\n\n"
+	if body := resp.Body.String(); body != expected {
+		t.Errorf("事件流内容错误: 得到 %q, 期待 %q", body, expected)
+	}
+	if !resp.Flushed {
+		t.Error("事件未刷新")
+	}
+}
+
+// 测试客户端断开后停止发送事件与心跳
+func TestEventStreamDisconnect(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(reqCtx)
+	resp := &syncRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ctx := NewContext(resp, req, DefaultConfig())
+
+	stream := ctx.SSE()
+	stop := stream.Heartbeat(5 * time.Millisecond)
+	defer stop()
+
+	deadline := time.After(time.Second)
+	for !strings.Contains(resp.body(), ": heartbeat\n\n") {
+		select {
+		case <-deadline:
+			t.Fatal("未收到心跳")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-stream.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done 通道未关闭")
+	}
+	if err := stream.Data("late"); err == nil {
+		t.Error("客户端断开后发送事件应返回错误")
+	}
+}
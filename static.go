@@ -1,11 +1,13 @@
 package kanggo
 
 import (
-	"fmt"
+	"errors"
 	"github.com/7836246/kanggo/constants"
+	"html/template"
+	"io/fs"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -13,15 +15,21 @@ import (
 
 // StaticConfig 配置结构体，定义静态文件服务的选项
 type StaticConfig struct {
-	Compress       bool                                     // 是否启用压缩，减少传输体积，默认值 false
-	ByteRange      bool                                     // 是否支持字节范围请求，默认值 false
+	Compress       bool                                     // 是否启用压缩：优先发送预压缩的 .br/.gz 文件，否则实时 gzip 压缩，默认值 false
+	ByteRange      bool                                     // 是否支持字节范围请求（Range），关闭时始终返回完整内容，默认值 false
 	Browse         bool                                     // 是否启用目录浏览，允许用户查看文件夹中的内容，默认值 false
+	BrowseHidden   bool                                     // 目录浏览中是否列出以 "." 开头的隐藏文件，默认值 false
+	BrowseTemplate *template.Template                       // 自定义目录浏览页面的模板，以 DirectoryListing 作为数据执行，默认使用内置模板
 	Download       bool                                     // 是否启用文件下载，启用后所有文件将以附件形式下载，默认值 false
 	Index          string                                   // 用于提供目录的索引文件的名称，例如 "index.html"，默认值为 "index.html"
-	CacheDuration  time.Duration                            // 非活动文件处理程序的缓存持续时间，使用负值禁用此选项，默认值 10 秒
+	CacheDuration  time.Duration                            // 实时压缩结果的缓存时间，文件修改后缓存立即失效，使用负值禁用缓存，默认值 10 秒
 	MaxAge         int                                      // 设置文件响应的 Cache-Control HTTP 头的值，MaxAge 以秒为单位，默认值 0
 	ModifyResponse func(http.ResponseWriter, *http.Request) // 自定义函数，允许修改响应，默认值为 nil
 	Next           func(*Context) bool                      // 定义一个函数，当返回 true 时跳过此中间件，默认值为 nil
+	SPA            bool                                     // 是否启用单页应用模式：不存在且没有扩展名的路径返回 SPAFallback，默认值 false
+	SPAFallback    string                                   // 单页应用的入口文件，以 no-cache 发送，默认值 "index.html"
+	SPAExclude     []string                                 // 单页应用模式下不回退到入口文件的请求路径前缀，例如 "/api"，默认值为 nil
+	IsHashedAsset  func(name string) bool                   // 单页应用模式下判断文件名是否带内容哈希，匹配的文件使用一年的 immutable 缓存，默认识别 main.3f9a2b1c.js 与 index-D3k2Lx9a.js
 }
 
 // NewStaticConfig 返回一个带有默认值的 StaticConfig 配置实例
@@ -30,21 +38,51 @@ func NewStaticConfig() StaticConfig {
 		Compress:       false,
 		ByteRange:      false,
 		Browse:         false,
+		BrowseHidden:   false,
+		BrowseTemplate: nil,
 		Download:       false,
 		Index:          "index.html",
 		CacheDuration:  10 * time.Second,
 		MaxAge:         0,
 		ModifyResponse: nil,
 		Next:           nil,
+		SPA:            false,
+		SPAFallback:    "index.html",
+		SPAExclude:     nil,
+		IsHashedAsset:  isHashedAsset,
 	}
 }
 
-// Static 注册一个静态文件服务路由
-func (k *KangGo) Static(prefix, root string, config ...StaticConfig) *KangGo {
+// staticConfigDefault 为未设置的配置项填充默认值
+func staticConfigDefault(config ...StaticConfig) StaticConfig {
 	cfg := NewStaticConfig()
 	if len(config) > 0 {
 		cfg = config[0]
+		if cfg.Index == "" {
+			cfg.Index = "index.html"
+		}
+		if cfg.CacheDuration == 0 {
+			cfg.CacheDuration = 10 * time.Second
+		}
+		if cfg.SPAFallback == "" {
+			cfg.SPAFallback = "index.html"
+		}
+		if cfg.IsHashedAsset == nil {
+			cfg.IsHashedAsset = isHashedAsset
+		}
 	}
+	return cfg
+}
+
+// Static 注册一个静态文件服务路由，root 为本地目录
+func (k *KangGo) Static(prefix, root string, config ...StaticConfig) *KangGo {
+	return k.StaticFS(prefix, os.DirFS(root), config...)
+}
+
+// StaticFS 注册一个从 fs.FS（例如 embed.FS）提供文件的静态文件服务路由
+// 请求路径会被清理为 fs.FS 内的相对路径，无法通过 ".." 访问 fsys 之外的文件
+func (k *KangGo) StaticFS(prefix string, fsys fs.FS, config ...StaticConfig) *KangGo {
+	cfg := staticConfigDefault(config...)
 
 	// 确保前缀总是以 '/' 开头
 	if !strings.HasPrefix(prefix, "/") {
@@ -54,79 +92,126 @@ func (k *KangGo) Static(prefix, root string, config ...StaticConfig) *KangGo {
 	// 去除前缀中的尾部斜杠，确保前缀统一
 	prefix = strings.TrimSuffix(prefix, "/")
 
+	var compressor *staticCompressor
+	if cfg.Compress {
+		compressor = newStaticCompressor(fsys, cfg.CacheDuration)
+	}
+
 	handler := func(ctx *Context) error {
 		if cfg.Next != nil && cfg.Next(ctx) {
 			return nil
 		}
 
-		// 获取相对路径
-		relativePath := strings.TrimPrefix(ctx.Request.URL.Path, prefix)
-		relativePath = strings.TrimPrefix(relativePath, "/")
-		filePath := filepath.Join(root, relativePath)
+		// 文件路由匹配后 URL.Path 已去掉前缀，只需清理为 fs.FS 内的相对路径，不能再次去除前缀
+		name := cleanFSPath(ctx.Request.URL.Path)
 
-		// 检查文件或目录是否存在
-		info, err := os.Stat(filePath)
-		if os.IsNotExist(err) {
-			http.NotFound(ctx.Writer, ctx.Request)
-			return nil
-		} else if err != nil {
-			http.Error(ctx.Writer, "500 服务器内部错误", http.StatusInternalServerError)
-			return nil
+		// 检查文件或目录是否存在，单页应用模式下回退到入口文件
+		info, err := fs.Stat(fsys, name)
+		if err != nil && cfg.SPA && errors.Is(err, fs.ErrNotExist) && spaShouldFallback(ctx, cfg, name) {
+			name = cleanFSPath(cfg.SPAFallback)
+			info, err = fs.Stat(fsys, name)
+		}
+		if err != nil {
+			return fileError(err)
 		}
 
 		// 处理目录请求
 		if info.IsDir() {
-			indexFile := filepath.Join(filePath, cfg.Index)
-			if _, err := os.Stat(indexFile); err == nil {
-				http.ServeFile(ctx.Writer, ctx.Request, indexFile)
-				return nil
+			// 目录地址必须以 "/" 结尾，否则目录页中的相对链接会指向上一级
+			if !strings.HasSuffix(ctx.path, "/") {
+				return ctx.Redirect(http.StatusMovedPermanently, directoryURL(ctx))
 			}
-			if cfg.Browse {
-				return browseDirectory(ctx.Writer, filePath)
+			indexFile := path.Join(name, cfg.Index)
+			if indexInfo, err := fs.Stat(fsys, indexFile); err == nil && !indexInfo.IsDir() {
+				name = indexFile
+			} else if cfg.Browse {
+				return browseDirectory(ctx, fsys, prefix, name, cfg)
+			} else {
+				return ErrForbidden
 			}
-			http.Error(ctx.Writer, "403 禁止访问目录", http.StatusForbidden)
-			return nil
 		}
 
 		// 处理文件请求
-		if cfg.MaxAge > 0 {
-			ctx.Writer.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(cfg.MaxAge))
-		}
-		if cfg.ByteRange {
-			ctx.Writer.Header().Set("Accept-Ranges", "bytes")
-		}
-		if cfg.Download {
-			ctx.Writer.Header().Set("Content-Disposition", "attachment")
+		switch {
+		case cfg.SPA && path.Ext(name) == ".html":
+			// 入口页面必须每次向服务器确认，才能及时引用新版本的资源
+			ctx.Writer.Header().Set(constants.HeaderCacheControl, "no-cache")
+		case cfg.SPA && cfg.IsHashedAsset(name):
+			// 文件名带内容哈希的资源内容永远不会变化
+			ctx.Writer.Header().Set(constants.HeaderCacheControl, "public, max-age=31536000, immutable")
+		case cfg.MaxAge > 0:
+			ctx.Writer.Header().Set(constants.HeaderCacheControl, "public, max-age="+strconv.Itoa(cfg.MaxAge))
 		}
 		if cfg.ModifyResponse != nil {
 			cfg.ModifyResponse(ctx.Writer, ctx.Request)
 		}
 
-		http.ServeFile(ctx.Writer, ctx.Request, filePath)
-		return nil
+		opts := sendFileOptions{download: cfg.Download, noRange: !cfg.ByteRange}
+		if compressor != nil {
+			if info, err = fs.Stat(fsys, name); err != nil {
+				return fileError(err)
+			}
+			if served, err := compressor.serve(ctx, name, info, opts); served || err != nil {
+				return err
+			}
+		}
+
+		file, err := fsys.Open(name)
+		if err != nil {
+			return fileError(err)
+		}
+		return ctx.serveFile(file, opts)
 	}
 
 	k.Router.Handle(constants.MethodGet, prefix+"/*", handler)
 	return k
 }
 
-// browseDirectory 提供简单的目录浏览功能
-func browseDirectory(w http.ResponseWriter, dirPath string) error {
-	files, err := os.ReadDir(dirPath)
-	if err != nil {
-		http.Error(w, "500 服务器内部错误", http.StatusInternalServerError)
-		return err
+// spaShouldFallback 判断不存在的路径是否应回退到单页应用的入口文件
+// 只有 GET/HEAD 请求、没有扩展名（不像静态资源）且不在 SPAExclude 中的路径才会回退
+func spaShouldFallback(ctx *Context, cfg StaticConfig, name string) bool {
+	if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+		return false
+	}
+	if path.Ext(name) != "" {
+		return false
 	}
+	for _, prefix := range cfg.SPAExclude {
+		if hasPathPrefix(ctx.path, strings.TrimSuffix(prefix, "/")) {
+			return false
+		}
+	}
+	return true
+}
 
-	// 构建简单的目录列表 HTML
-	fmt.Fprintf(w, "<html><body><h1>目录浏览</h1><ul>")
-	for _, file := range files {
-		name := file.Name()
-		if file.IsDir() {
-			name += "/"
+// isHashedAsset 判断文件名是否带有构建工具生成的内容哈希
+// 哈希位于最后一个 "." 或 "-" 之后、扩展名之前，至少 8 个字母、数字或下划线且包含数字，
+// 例如 main.3f9a2b1c.js（webpack）与 index-D3k2Lx9a.js（Vite）；app-settings.js 不会被误判
+func isHashedAsset(name string) bool {
+	base := path.Base(name)
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	i := strings.LastIndexAny(stem, ".-")
+	if i < 0 || len(stem)-i-1 < 8 || stem == base {
+		return false
+	}
+	hasDigit := false
+	for _, r := range stem[i+1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		default:
+			return false
 		}
-		fmt.Fprintf(w, "<li><a href=\"%s\">%s</a></li>", name, name)
 	}
-	fmt.Fprint(w, "</ul></body></html>")
-	return nil
+	return hasDigit
+}
+
+// directoryURL 返回在请求路径末尾加上 "/" 后的地址，保留查询参数
+func directoryURL(ctx *Context) string {
+	location := ctx.path + "/"
+	if ctx.Request.URL.RawQuery != "" {
+		location += "?" + ctx.Request.URL.RawQuery
+	}
+	return location
 }
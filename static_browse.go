@@ -0,0 +1,236 @@
+package kanggo
+
+import (
+	"bytes"
+	"cmp"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// DirectoryListing 是目录浏览页面的数据，同时作为 JSON 列表的响应体
+// 自定义 StaticConfig.BrowseTemplate 时，模板以它作为根数据执行
+type DirectoryListing struct {
+	Path        string           `json:"path"`        // 当前目录的请求路径，以 "/" 结尾
+	Breadcrumbs []Breadcrumb     `json:"breadcrumbs"` // 从挂载点到当前目录的导航路径
+	Entries     []DirectoryEntry `json:"entries"`     // 目录中的条目，目录排在文件之前
+	Sort        string           `json:"sort"`        // 排序字段：name、size 或 modtime
+	Order       string           `json:"order"`       // 排序方向：asc 或 desc
+}
+
+// DirectoryEntry 表示目录中的一个文件或子目录
+type DirectoryEntry struct {
+	Name    string    `json:"name"`    // 文件名，子目录以 "/" 结尾
+	URL     string    `json:"url"`     // 相对于当前目录的链接，已进行 URL 编码
+	IsDir   bool      `json:"isDir"`   // 是否为目录
+	Size    int64     `json:"size"`    // 文件大小，目录为 0
+	ModTime time.Time `json:"modTime"` // 最后修改时间
+}
+
+// Breadcrumb 是目录浏览页面导航路径中的一级
+type Breadcrumb struct {
+	Name string `json:"name"` // 显示名称
+	URL  string `json:"url"`  // 绝对路径链接，已进行 URL 编码
+}
+
+// SortURL 返回按 column 排序的查询地址，column 已是当前排序字段时切换排序方向
+func (l DirectoryListing) SortURL(column string) string {
+	order := "asc"
+	if l.Sort == column && l.Order == "asc" {
+		order = "desc"
+	}
+	return "?sort=" + url.QueryEscape(column) + "&order=" + order
+}
+
+// HumanSize 返回便于阅读的文件大小，例如 1.5 KB，目录返回 "-"
+func (e DirectoryEntry) HumanSize() string {
+	if e.IsDir {
+		return "-"
+	}
+	const unit = 1024
+	if e.Size < unit {
+		return strconv.FormatInt(e.Size, 10) + " B"
+	}
+	size, exp := float64(e.Size)/unit, 0
+	for size >= unit && exp < 4 {
+		size /= unit
+		exp++
+	}
+	return strconv.FormatFloat(size, 'f', 1, 64) + " " + string("KMGTP"[exp]) + "B"
+}
+
+// defaultBrowseTemplate 默认的目录浏览页面，html/template 会对文件名等内容进行转义
+var defaultBrowseTemplate = template.Must(template.New("browse").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>目录浏览 {{.Path}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;margin:2em;color:#222}
+nav a{text-decoration:none}
+table{border-collapse:collapse;width:100%;max-width:960px}
+th,td{padding:.4em .8em;text-align:left;border-bottom:1px solid #eee}
+th a{color:inherit}
+td.size{text-align:right;white-space:nowrap}
+</style>
+</head>
+<body>
+<h1>目录浏览</h1>
+<nav>{{range $i, $b := .Breadcrumbs}}{{if $i}} / {{end}}<a href="{{$b.URL}}">{{$b.Name}}</a>{{end}}</nav>
+<table>
+<thead><tr>
+<th><a href="{{.SortURL "name"}}">名称</a></th>
+<th><a href="{{.SortURL "size"}}">大小</a></th>
+<th><a href="{{.SortURL "modtime"}}">修改时间</a></th>
+</tr></thead>
+<tbody>
+{{- if gt (len .Breadcrumbs) 1}}
+<tr><td><a href="../">../</a></td><td class="size">-</td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.URL}}">{{.Name}}</a></td><td class="size">{{.HumanSize}}</td><td>{{.ModTime.Format "2006-01-02 15:04:05"}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// browseDirectory 列出目录内容，请求头 Accept 偏好 application/json 时返回 JSON，否则渲染 HTML 页面
+// prefix 为路由的挂载点（不含末尾的 "/"），dir 为清理后的 fs.FS 目录名
+func browseDirectory(ctx *Context, fsys fs.FS, prefix, dir string, cfg StaticConfig) error {
+	listing, err := newDirectoryListing(ctx, fsys, prefix, dir, cfg.BrowseHidden)
+	if err != nil {
+		return fileError(err)
+	}
+
+	ctx.Writer.Header().Add(constants.HeaderVary, constants.HeaderAccept)
+	if ctx.Accepts(constants.MIMETextHTML, constants.MIMEApplicationJSON) == constants.MIMEApplicationJSON {
+		return ctx.JSON(constants.StatusOK, listing)
+	}
+
+	tmpl := cfg.BrowseTemplate
+	if tmpl == nil {
+		tmpl = defaultBrowseTemplate
+	}
+	// 先渲染到缓冲区，模板执行失败时不会发送残缺的页面
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, listing); err != nil {
+		return err
+	}
+	return ctx.send(constants.StatusOK, constants.MIMETextHTMLCharsetUTF8, buf.Bytes())
+}
+
+// newDirectoryListing 读取目录并按查询参数 sort 与 order 排序，showHidden 为 false 时忽略以 "." 开头的条目
+// 路径与面包屑由挂载点与清理后的目录名生成，不受 "/files/a//b/" 这类不规范请求路径的影响
+func newDirectoryListing(ctx *Context, fsys fs.FS, prefix, dir string, showHidden bool) (DirectoryListing, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return DirectoryListing{}, err
+	}
+
+	dirPath := prefix + "/"
+	if dir != "." {
+		dirPath += dir + "/"
+	}
+	listing := DirectoryListing{
+		Path:        dirPath,
+		Breadcrumbs: breadcrumbs(prefix, dir),
+		Entries:     make([]DirectoryEntry, 0, len(files)),
+		Sort:        "name",
+		Order:       "asc",
+	}
+	query := ctx.Request.URL.Query()
+	switch s := query.Get("sort"); s {
+	case "size", "modtime":
+		listing.Sort = s
+	}
+	if query.Get("order") == "desc" {
+		listing.Order = "desc"
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if !showHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue // 读取目录后被删除的文件
+		}
+		entry := DirectoryEntry{
+			Name:    name,
+			URL:     "./" + url.PathEscape(name), // "./" 防止 "javascript:" 之类的文件名被当作协议
+			IsDir:   file.IsDir(),
+			ModTime: info.ModTime(),
+		}
+		if entry.IsDir {
+			entry.Name += "/"
+			entry.URL += "/"
+		} else {
+			entry.Size = info.Size()
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+
+	sortDirectoryEntries(listing.Entries, listing.Sort, listing.Order == "desc")
+	return listing, nil
+}
+
+// sortDirectoryEntries 排序目录条目，目录始终排在文件之前，大小或修改时间相同时按名称排序
+func sortDirectoryEntries(entries []DirectoryEntry, by string, desc bool) {
+	slices.SortFunc(entries, func(a, b DirectoryEntry) int {
+		if a.IsDir != b.IsDir {
+			if a.IsDir {
+				return -1
+			}
+			return 1
+		}
+		var order int
+		switch by {
+		case "size":
+			order = cmp.Compare(a.Size, b.Size)
+		case "modtime":
+			order = a.ModTime.Compare(b.ModTime)
+		default:
+			order = strings.Compare(a.Name, b.Name)
+		}
+		if desc {
+			order = -order
+		}
+		if order == 0 {
+			order = strings.Compare(a.Name, b.Name)
+		}
+		return order
+	})
+}
+
+// breadcrumbs 生成从挂载点 prefix 到目录 dir 的导航路径，dir 是 fs.FS 中清理后的目录名
+func breadcrumbs(prefix, dir string) []Breadcrumb {
+	root := prefix + "/"
+	var segments []string
+	if dir != "." {
+		segments = strings.Split(dir, "/")
+	}
+
+	crumbs := make([]Breadcrumb, 0, len(segments)+1)
+	crumbs = append(crumbs, Breadcrumb{Name: root, URL: escapePath(root)})
+	current := root
+	for _, segment := range segments {
+		current += segment + "/"
+		crumbs = append(crumbs, Breadcrumb{Name: segment, URL: escapePath(current)})
+	}
+	return crumbs
+}
+
+// escapePath 对路径进行 URL 编码，保留 "/" 分隔符
+func escapePath(p string) string {
+	return (&url.URL{Path: p}).EscapedPath()
+}
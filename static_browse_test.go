@@ -0,0 +1,137 @@
+package kanggo
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// newBrowseFS 创建用于目录浏览测试的文件系统
+func newBrowseFS() fstest.MapFS {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return fstest.MapFS{
+		"docs/a.txt":                            {Data: []byte("aaaa"), ModTime: now.Add(-time.Hour)},
+		"docs/b.txt":                            {Data: []byte("b"), ModTime: now},
+		"docs/c.txt":                            {Data: make([]byte, 2048), ModTime: now.Add(-2 * time.Hour)},
+		"docs/sub/deep.txt":                     {Data: []byte("deep")},
+		"docs/.env":                             {Data: []byte("SECRET=1")},
+		"docs/<img src=x onerror=alert(1)>.txt": {Data: []byte("x")},
+		"docs/javascript:alert(1)":              {Data: []byte("x")},
+	}
+}
+
+// 测试目录浏览页面的转义、隐藏文件过滤与面包屑导航
+func TestBrowseHTML(t *testing.T) {
+	app := New(Config{})
+	app.StaticFS("/files", newBrowseFS(), StaticConfig{Browse: true})
+
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/files/docs/", nil))
+	body := resp.Body.String()
+	if resp.Code != http.StatusOK {
+		t.Fatalf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusOK)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type 错误: 得到 %v", ct)
+	}
+	if strings.Contains(body, "<img src=x onerror=alert(1)>") {
+		t.Error("文件名未进行 HTML 转义")
+	}
+	if !strings.Contains(body, "&lt;img src=x") {
+		t.Error("转义后的文件名未出现在页面中")
+	}
+	if strings.Contains(body, `href="javascript:`) {
+		t.Error("文件名被当作 javascript: 链接")
+	}
+	if strings.Contains(body, ".env") {
+		t.Error("默认不应列出隐藏文件")
+	}
+	for _, want := range []string{`href="/files/"`, `href="/files/docs/"`, `href="./sub/"`, "2.0 KB"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("页面缺少 %q", want)
+		}
+	}
+}
+
+// 测试不规范的请求路径不影响目录路径与面包屑
+func TestBrowseUncleanPath(t *testing.T) {
+	app := New(Config{})
+	app.StaticFS("/files", newBrowseFS(), StaticConfig{Browse: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/files/docs//sub/", nil)
+	req.Header.Set("Accept", "application/json")
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, req)
+
+	var listing DirectoryListing
+	if err := json.Unmarshal(resp.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("解析 JSON 失败: %v, 内容 %q", err, resp.Body.String())
+	}
+	if listing.Path != "/files/docs/sub/" {
+		t.Errorf("目录路径错误: 得到 %v, 期待 %v", listing.Path, "/files/docs/sub/")
+	}
+	var urls []string
+	for _, crumb := range listing.Breadcrumbs {
+		urls = append(urls, crumb.URL)
+	}
+	if want := "/files/,/files/docs/,/files/docs/sub/"; strings.Join(urls, ",") != want {
+		t.Errorf("面包屑错误: 得到 %v, 期待 %v", urls, want)
+	}
+}
+
+// 测试 Accept: application/json 时返回 JSON 列表与排序
+func TestBrowseJSON(t *testing.T) {
+	app := New(Config{})
+	app.StaticFS("/files", newBrowseFS(), StaticConfig{Browse: true, BrowseHidden: true})
+
+	cases := []struct {
+		query string
+		names []string
+	}{
+		{"", []string{"sub/", ".env", "<img src=x onerror=alert(1)>.txt", "a.txt", "b.txt", "c.txt", "javascript:alert(1)"}},
+		{"?sort=size&order=desc", []string{"sub/", "c.txt", ".env", "a.txt", "<img src=x onerror=alert(1)>.txt", "b.txt", "javascript:alert(1)"}},
+		{"?sort=modtime", []string{"sub/", ".env", "<img src=x onerror=alert(1)>.txt", "javascript:alert(1)", "c.txt", "a.txt", "b.txt"}},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/files/docs/"+tc.query, nil)
+		req.Header.Set("Accept", "application/json")
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, req)
+
+		if ct := resp.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Fatalf("Content-Type 错误: 得到 %v", ct)
+		}
+		if vary := resp.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("Vary 头错误: 得到 %v", vary)
+		}
+		var listing DirectoryListing
+		if err := json.Unmarshal(resp.Body.Bytes(), &listing); err != nil {
+			t.Fatalf("解析 JSON 失败: %v", err)
+		}
+		var names []string
+		for _, entry := range listing.Entries {
+			names = append(names, entry.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tc.names, ",") {
+			t.Errorf("%q: 条目顺序错误: 得到 %v, 期待 %v", tc.query, names, tc.names)
+		}
+	}
+}
+
+// 测试自定义目录浏览模板
+func TestBrowseTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("list").Parse(`{{range .Entries}}[{{.Name}}]{{end}}`))
+	app := New(Config{})
+	app.StaticFS("/files", newBrowseFS(), StaticConfig{Browse: true, BrowseTemplate: tmpl})
+
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/files/docs/sub/", nil))
+	if resp.Body.String() != "[deep.txt]" {
+		t.Errorf("响应内容错误: 得到 %q, 期待 %q", resp.Body.String(), "[deep.txt]")
+	}
+}
@@ -0,0 +1,181 @@
+package kanggo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// minCompressSize 小于该大小的文件不进行实时压缩，压缩带来的收益不足以抵消开销
+const minCompressSize = 1024
+
+// precompressed 预压缩文件的扩展名与对应的 Content-Encoding，按优先级排列
+var precompressed = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// staticCompressor 为静态文件提供压缩：优先使用预压缩的 .br/.gz 文件，否则实时进行 gzip 压缩并缓存结果
+type staticCompressor struct {
+	fsys          fs.FS
+	cacheDuration time.Duration // 压缩结果的缓存时间，负值表示不缓存
+	mu            sync.Mutex
+	cache         map[string]*compressedFile
+}
+
+// compressedFile 是缓存的实时压缩结果
+type compressedFile struct {
+	data    []byte
+	etag    string
+	modTime time.Time
+	size    int64
+	expires time.Time
+}
+
+// newStaticCompressor 创建 staticCompressor
+func newStaticCompressor(fsys fs.FS, cacheDuration time.Duration) *staticCompressor {
+	return &staticCompressor{
+		fsys:          fsys,
+		cacheDuration: cacheDuration,
+		cache:         make(map[string]*compressedFile),
+	}
+}
+
+// serve 尝试发送 name 的压缩版本，客户端不接受压缩或文件不适合压缩时返回 false
+func (sc *staticCompressor) serve(ctx *Context, name string, info fs.FileInfo, opts sendFileOptions) (bool, error) {
+	header := ctx.Writer.Header()
+	header.Add(constants.HeaderVary, constants.HeaderAcceptEncoding)
+
+	// 无法通过扩展名确定类型时不压缩，避免 http.ServeContent 对压缩后的内容进行嗅探
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" || ctx.Request.Header.Get(constants.HeaderAcceptEncoding) == "" {
+		return false, nil
+	}
+
+	// 找出可用的编码，交给内容协商选择
+	offers := make([]string, 0, len(precompressed)+1)
+	siblings := make(map[string]string, len(precompressed))
+	for _, p := range precompressed {
+		if siblingInfo, err := fs.Stat(sc.fsys, name+p.ext); err == nil && !siblingInfo.IsDir() {
+			offers = append(offers, p.encoding)
+			siblings[p.encoding] = name + p.ext
+		}
+	}
+	canCompress := info.Size() >= minCompressSize && isCompressible(contentType)
+	if _, ok := siblings["gzip"]; !ok && canCompress {
+		offers = append(offers, "gzip")
+	}
+	if len(offers) == 0 {
+		return false, nil
+	}
+	encoding := ctx.AcceptsEncodings(append(offers, "identity")...)
+	if encoding == "" || encoding == "identity" {
+		return false, nil
+	}
+
+	header.Set(constants.HeaderContentType, contentType)
+	opts.encoding = encoding
+	if sibling, ok := siblings[encoding]; ok {
+		file, err := sc.fsys.Open(sibling)
+		if err != nil {
+			return false, fileError(err)
+		}
+		defer file.Close()
+		content, ok := file.(io.ReadSeeker)
+		if !ok {
+			data, err := io.ReadAll(file)
+			if err != nil {
+				return false, err
+			}
+			content = bytes.NewReader(data)
+		}
+		etag, err := fileETag(info, content)
+		if err != nil {
+			return false, err
+		}
+		return true, ctx.serveContent(info.Name(), info.ModTime(), etag, content, opts)
+	}
+
+	compressed, err := sc.compress(name, info)
+	if err != nil {
+		return false, err
+	}
+	return true, ctx.serveContent(info.Name(), info.ModTime(), compressed.etag, bytes.NewReader(compressed.data), opts)
+}
+
+// compress 返回 name 的 gzip 压缩结果，缓存未过期且文件未修改时直接使用缓存
+func (sc *staticCompressor) compress(name string, info fs.FileInfo) (*compressedFile, error) {
+	now := time.Now()
+	if sc.cacheDuration >= 0 {
+		sc.mu.Lock()
+		cached, ok := sc.cache[name]
+		sc.mu.Unlock()
+		if ok && now.Before(cached.expires) && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+			return cached, nil
+		}
+	}
+
+	data, err := fs.ReadFile(sc.fsys, name)
+	if err != nil {
+		return nil, fileError(err)
+	}
+	etag, err := fileETag(info, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err = zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+
+	compressed := &compressedFile{
+		data:    buf.Bytes(),
+		etag:    etag,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		expires: now.Add(sc.cacheDuration),
+	}
+	if sc.cacheDuration >= 0 {
+		sc.mu.Lock()
+		for key, entry := range sc.cache {
+			if now.After(entry.expires) {
+				delete(sc.cache, key) // 顺便清理过期的缓存
+			}
+		}
+		sc.cache[name] = compressed
+		sc.mu.Unlock()
+	}
+	return compressed, nil
+}
+
+// isCompressible 判断该类型的内容是否值得压缩，图片、视频、压缩包等已经压缩过的格式不再压缩
+func isCompressible(contentType string) bool {
+	typ := mediaType(contentType)
+	switch {
+	case strings.HasPrefix(typ, "text/"),
+		strings.HasSuffix(typ, "+json"),
+		strings.HasSuffix(typ, "+xml"):
+		return true
+	}
+	switch typ {
+	case "application/javascript", "application/json", "application/xml",
+		"application/wasm", "image/svg+xml", "font/ttf", "font/otf":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package kanggo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// 测试预压缩文件、实时压缩与内容协商
+func TestStaticCompress(t *testing.T) {
+	script := strings.Repeat("console.log('kanggo');\n", 100)
+	fsys := fstest.MapFS{
+		"app.js":     {Data: []byte(script)},
+		"app.js.br":  {Data: []byte("brotli-bytes")},
+		"style.css":  {Data: []byte(strings.Repeat("body { margin: 0; }\n", 100))},
+		"tiny.txt":   {Data: []byte("tiny")},
+		"photo.png":  {Data: bytes.Repeat([]byte{0x89}, 2048)},
+		"index.html": {Data: []byte("<h1>home</h1>")},
+	}
+	app := New(Config{})
+	app.StaticFS("/", fsys, StaticConfig{Compress: true})
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	cases := []struct {
+		path, acceptEncoding, encoding string
+	}{
+		{"/app.js", "gzip, br", "br"},
+		{"/app.js", "gzip", "gzip"},
+		{"/app.js", "", ""},
+		{"/app.js", "br;q=0, gzip;q=0, identity", ""},
+		{"/style.css", "br, gzip", "gzip"},
+		{"/tiny.txt", "gzip", ""},
+		{"/photo.png", "gzip", ""},
+	}
+	for _, tc := range cases {
+		resp := get(tc.path, tc.acceptEncoding)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("%s: 状态码错误: 得到 %v", tc.path, resp.Code)
+		}
+		if ce := resp.Header().Get("Content-Encoding"); ce != tc.encoding {
+			t.Errorf("%s (%q): Content-Encoding 得到 %q, 期待 %q", tc.path, tc.acceptEncoding, ce, tc.encoding)
+		}
+		if vary := resp.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%s: Vary 错误: 得到 %q", tc.path, vary)
+		}
+	}
+
+	resp := get("/app.js", "br")
+	if resp.Body.String() != "brotli-bytes" || !strings.HasPrefix(resp.Header().Get("Content-Type"), "text/javascript") {
+		t.Errorf("预压缩文件错误: 内容 %q, Content-Type %q", resp.Body.String(), resp.Header().Get("Content-Type"))
+	}
+
+	resp = get("/style.css", "gzip")
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("无法解压响应: %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	if string(plain) != string(fsys["style.css"].Data) {
+		t.Error("解压后的内容与原文件不一致")
+	}
+	if identity := get("/style.css", ""); identity.Header().Get("ETag") == resp.Header().Get("ETag") {
+		t.Error("压缩与未压缩的表示应使用不同的 ETag")
+	}
+}
+
+// 测试实时压缩结果的缓存与过期
+func TestStaticCompressCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data.json": {Data: []byte(strings.Repeat(`{"k":"v"},`, 200)), ModTime: time.Unix(1, 0)},
+	}
+	sc := newStaticCompressor(fsys, time.Hour)
+	info, _ := fsys.Stat("data.json")
+
+	first, err := sc.compress("data.json", info)
+	if err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+	if second, _ := sc.compress("data.json", info); second != first {
+		t.Error("缓存有效期内应复用压缩结果")
+	}
+
+	// 文件修改后缓存失效
+	fsys["data.json"] = &fstest.MapFile{Data: []byte(strings.Repeat(`{"k":"w"},`, 200)), ModTime: time.Unix(2, 0)}
+	info, _ = fsys.Stat("data.json")
+	if third, _ := sc.compress("data.json", info); third == first {
+		t.Error("文件修改后应重新压缩")
+	}
+
+	// 负值禁用缓存
+	sc = newStaticCompressor(fsys, -1)
+	a, _ := sc.compress("data.json", info)
+	if b, _ := sc.compress("data.json", info); a == b || len(sc.cache) != 0 {
+		t.Error("CacheDuration 为负值时不应缓存")
+	}
+}
+
+// 测试 ByteRange 开关
+func TestStaticByteRange(t *testing.T) {
+	fsys := fstest.MapFS{"data.txt": {Data: []byte("0123456789")}}
+	for _, byteRange := range []bool{true, false} {
+		app := New(Config{})
+		app.StaticFS("/files", fsys, StaticConfig{ByteRange: byteRange})
+
+		req := httptest.NewRequest(http.MethodGet, "/files/data.txt", nil)
+		req.Header.Set("Range", "bytes=0-3")
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, req)
+
+		if byteRange {
+			if resp.Code != http.StatusPartialContent || resp.Body.String() != "0123" || resp.Header().Get("Accept-Ranges") != "bytes" {
+				t.Errorf("启用范围请求: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+			}
+		} else if resp.Code != http.StatusOK || resp.Body.String() != "0123456789" || resp.Header().Get("Accept-Ranges") != "none" {
+			t.Errorf("禁用范围请求: 状态码 %v, 内容 %q, Accept-Ranges %q", resp.Code, resp.Body.String(), resp.Header().Get("Accept-Ranges"))
+		}
+		if r := req.Header.Get("Range"); r != "bytes=0-3" {
+			t.Errorf("不应修改原始请求的 Range 头: 得到 %q", r)
+		}
+	}
+}
@@ -3,8 +3,12 @@ package kanggo
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -77,3 +81,194 @@ func TestStatic(t *testing.T) {
 		t.Errorf("Cache-Control 头错误: 得到 %v 期待 %v", cacheControl, "public, max-age=3600")
 	}
 }
+
+// 测试从 fs.FS 提供静态文件：索引文件、目录浏览、目录重定向与缓存头
+func TestStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<h1>home</h1>")},
+		"css/site.css":      {Data: []byte("body{}")},
+		"docs/guide.txt":    {Data: []byte("guide")},
+		"private/notes.txt": {Data: []byte("notes")},
+		"x.txt":             {Data: []byte("root x")},
+		"assets/x.txt":      {Data: []byte("nested x")},
+	}
+
+	app := New(Config{})
+	app.StaticFS("/assets", fsys, StaticConfig{MaxAge: 60})
+	app.StaticFS("/browse", fsys, StaticConfig{Browse: true})
+
+	cases := []struct {
+		path     string
+		code     int
+		contains string
+	}{
+		{"/assets/css/site.css", http.StatusOK, "body{}"},
+		{"/assets/", http.StatusOK, "<h1>home</h1>"},
+		{"/assets/docs", http.StatusMovedPermanently, ""},
+		{"/assets/docs/", http.StatusForbidden, ""},
+		{"/assets/missing.txt", http.StatusNotFound, ""},
+		{"/browse/docs/", http.StatusOK, "guide.txt"},
+		// 与前缀同名的子目录不能被误当作前缀再次去除
+		{"/assets/x.txt", http.StatusOK, "root x"},
+		{"/assets/assets/x.txt", http.StatusOK, "nested x"},
+	}
+	for _, tc := range cases {
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if resp.Code != tc.code || !strings.Contains(resp.Body.String(), tc.contains) {
+			t.Errorf("%s: 状态码 %v, 内容 %q; 期待 %v, 包含 %q", tc.path, resp.Code, resp.Body.String(), tc.code, tc.contains)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/assets/css/site.css", nil))
+	if cc := resp.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Errorf("Cache-Control 头错误: 得到 %v", cc)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "text/css; charset=utf-8" {
+		t.Errorf("Content-Type 错误: 得到 %v", ct)
+	}
+
+	resp = httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/assets/docs?x=1", nil))
+	if location := resp.Header().Get("Location"); location != "/assets/docs/?x=1" {
+		t.Errorf("目录重定向地址错误: 得到 %v", location)
+	}
+}
+
+// 测试静态文件服务不能访问根目录之外的文件
+func TestStaticPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "public")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "ok.txt"), []byte("ok"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := New(Config{})
+	app.Static("/static", root)
+
+	for _, target := range []string{
+		"/static/../secret.txt",
+		"/static/%2e%2e/secret.txt",
+		"/static/..%2fsecret.txt",
+		"/static/sub/../../secret.txt",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = target
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			req.URL.Path = unescaped
+		}
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, req)
+		if strings.Contains(resp.Body.String(), "secret") {
+			t.Errorf("%s: 读取到了根目录之外的文件", target)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/static/sub/../ok.txt", nil))
+	if resp.Body.String() != "ok" {
+		t.Errorf("响应内容错误: 得到 %q, 期待 %q", resp.Body.String(), "ok")
+	}
+}
+
+// 测试单页应用模式的回退与缓存策略
+func TestStaticSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":               {Data: []byte("<div id=app></div>")},
+		"assets/index-D3k2Lx9a.js": {Data: []byte("console.log(1)")},
+		"assets/app-settings.js":   {Data: []byte("settings")},
+		"favicon.ico":              {Data: []byte("ico")},
+	}
+
+	app := New(Config{})
+	app.GET("/api/users", func(ctx *Context) error {
+		return ctx.SendString("users")
+	})
+	app.StaticFS("/", fsys, StaticConfig{SPA: true, SPAExclude: []string{"/api"}, MaxAge: 60})
+
+	cases := []struct {
+		method       string
+		path         string
+		code         int
+		contains     string
+		cacheControl string
+	}{
+		{http.MethodGet, "/dashboard/settings", http.StatusOK, "<div id=app>", "no-cache"},
+		{http.MethodGet, "/", http.StatusOK, "<div id=app>", "no-cache"},
+		{http.MethodGet, "/assets/index-D3k2Lx9a.js", http.StatusOK, "console.log", "public, max-age=31536000, immutable"},
+		{http.MethodGet, "/assets/app-settings.js", http.StatusOK, "settings", "public, max-age=60"},
+		{http.MethodGet, "/assets/missing.js", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/api/users", http.StatusOK, "users", ""},
+		{http.MethodGet, "/api/orders", http.StatusNotFound, "", ""},
+		{http.MethodPost, "/dashboard", http.StatusNotFound, "", ""},
+	}
+	for _, tc := range cases {
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, httptest.NewRequest(tc.method, tc.path, nil))
+		if resp.Code != tc.code || !strings.Contains(resp.Body.String(), tc.contains) {
+			t.Errorf("%s %s: 状态码 %v, 内容 %q; 期待 %v, 包含 %q", tc.method, tc.path, resp.Code, resp.Body.String(), tc.code, tc.contains)
+		}
+		if tc.code == http.StatusOK {
+			if cc := resp.Header().Get("Cache-Control"); cc != tc.cacheControl {
+				t.Errorf("%s: Cache-Control 头错误: 得到 %q, 期待 %q", tc.path, cc, tc.cacheControl)
+			}
+		}
+	}
+}
+
+// 测试挂载在前缀下的文件路由优先于动态路由，挂载在根路径的文件路由排在动态路由之后
+func TestStaticPrecedence(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":     {Data: []byte("console.log(1)")},
+		"index.html": {Data: []byte("<div id=app></div>")},
+	}
+
+	app := New(Config{})
+	app.GET("/:a/:b", func(ctx *Context) error {
+		return ctx.SendString("dynamic " + ctx.Param("a") + "/" + ctx.Param("b"))
+	})
+	app.StaticFS("/static", fsys)
+	app.StaticFS("/", fsys, StaticConfig{SPA: true})
+
+	cases := []struct {
+		path     string
+		code     int
+		contains string
+	}{
+		{"/static/app.js", http.StatusOK, "console.log"},
+		{"/static/missing.js", http.StatusNotFound, ""},
+		{"/users/1", http.StatusOK, "dynamic users/1"},
+		{"/dashboard", http.StatusOK, "<div id=app>"},
+	}
+	for _, tc := range cases {
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if resp.Code != tc.code || !strings.Contains(resp.Body.String(), tc.contains) {
+			t.Errorf("%s: 状态码 %v, 内容 %q; 期待 %v, 包含 %q", tc.path, resp.Code, resp.Body.String(), tc.code, tc.contains)
+		}
+	}
+}
+
+// 测试带内容哈希的文件名识别
+func TestIsHashedAsset(t *testing.T) {
+	cases := map[string]bool{
+		"assets/index-D3k2Lx9a.js":  true,
+		"js/main.3f9a2b1c.js":       true,
+		"assets/app-settings.js":    false,
+		"assets/vendor-abcdefgh.js": false,
+		"index.html":                false,
+		"3f9a2b1c4d":                false,
+	}
+	for name, want := range cases {
+		if got := isHashedAsset(name); got != want {
+			t.Errorf("%s: 得到 %v, 期待 %v", name, got, want)
+		}
+	}
+}
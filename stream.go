@@ -0,0 +1,115 @@
+package kanggo
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// jsonSeqRecordSeparator RFC 7464 中 JSON 文本序列每条记录前的分隔符
+const jsonSeqRecordSeparator = 0x1E
+
+// Flush 将已写入的响应数据立即发送给客户端
+// 通过 http.ResponseController 逐层调用 Unwrap，因此被中间件包装过的 ResponseWriter 同样可以刷新
+func (c *Context) Flush() error {
+	err := http.NewResponseController(c.Writer).Flush()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil // 不支持刷新的 ResponseWriter 退化为普通的缓冲写入
+	}
+	return err
+}
+
+// Stream 以分块方式持续输出响应，每次调用 step 后都会刷新缓冲区
+// step 返回 false 时结束输出；客户端断开连接时也会提前结束，此时返回 true
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.Writer)
+			if err := c.Flush(); err != nil {
+				return true
+			}
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// SendStream 将 reader 中的内容作为响应体发送，size 大于等于 0 时设置 Content-Length
+// 若 reader 同时实现了 io.Closer，发送完毕后会将其关闭
+func (c *Context) SendStream(reader io.Reader, size ...int) error {
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	header := c.Writer.Header()
+	if header.Get(constants.HeaderContentType) == "" {
+		header.Set(constants.HeaderContentType, constants.MIMEOctetStream)
+	}
+	if len(size) > 0 && size[0] >= 0 {
+		header.Set(constants.HeaderContentLength, strconv.Itoa(size[0]))
+	}
+
+	_, err := io.Copy(c.Writer, reader)
+	return err
+}
+
+// StreamEncoder 逐条编码并立即刷新输出的 JSON 流，支持 NDJSON 与 JSON 文本序列（RFC 7464）
+type StreamEncoder struct {
+	ctx     *Context
+	encode  func(v interface{}) ([]byte, error)
+	jsonSeq bool
+}
+
+// NDJSON 以 application/x-ndjson 格式开始输出，每条记录占一行
+func (c *Context) NDJSON(code int) *StreamEncoder {
+	return c.newStreamEncoder(code, constants.MIMEApplicationNDJSON, false)
+}
+
+// JSONSeq 以 application/json-seq 格式开始输出，每条记录以 0x1E 开头、换行结尾
+func (c *Context) JSONSeq(code int) *StreamEncoder {
+	return c.newStreamEncoder(code, constants.MIMEApplicationJSONSeq, true)
+}
+
+// newStreamEncoder 写入响应头并创建 StreamEncoder
+func (c *Context) newStreamEncoder(code int, contentType string, jsonSeq bool) *StreamEncoder {
+	encode := c.jsonEncoder
+	if encode == nil {
+		encode = json.Marshal
+	}
+	c.Writer.Header().Set(constants.HeaderContentType, contentType)
+	c.Writer.Header().Del(constants.HeaderContentLength)
+	c.Writer.WriteHeader(code)
+	return &StreamEncoder{ctx: c, encode: encode, jsonSeq: jsonSeq}
+}
+
+// Encode 编码一条记录并立即刷新到客户端，客户端断开连接后返回其原因
+func (s *StreamEncoder) Encode(v interface{}) error {
+	if err := s.ctx.Request.Context().Err(); err != nil {
+		return err
+	}
+	data, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, 0, len(data)+2)
+	if s.jsonSeq {
+		record = append(record, jsonSeqRecordSeparator)
+	}
+	record = append(record, data...)
+	record = append(record, '\n')
+
+	if _, err = s.ctx.Writer.Write(record); err != nil {
+		return err
+	}
+	return s.ctx.Flush()
+}
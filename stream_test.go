@@ -0,0 +1,103 @@
+package kanggo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 测试 Stream 逐块输出并刷新
+func TestContextStream(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	router.Handle("GET", "/stream", func(ctx *Context) error {
+		count := 0
+		ctx.Stream(func(w io.Writer) bool {
+			count++
+			fmt.Fprintf(w, "chunk%d;", count)
+			return count < 3
+		})
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if body := resp.Body.String(); body != "chunk1;chunk2;chunk3;" {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", body, "chunk1;chunk2;chunk3;")
+	}
+	if !resp.Flushed {
+		t.Error("Stream 未刷新响应")
+	}
+}
+
+// 测试客户端断开后 Stream 提前结束
+func TestContextStreamClientGone(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx)
+	ctx := NewContext(httptest.NewRecorder(), req, DefaultConfig())
+
+	calls := 0
+	clientGone := ctx.Stream(func(w io.Writer) bool {
+		calls++
+		cancel()
+		return true
+	})
+	if !clientGone || calls != 1 {
+		t.Errorf("客户端断开后应结束输出: clientGone=%v, calls=%d", clientGone, calls)
+	}
+}
+
+// 测试 SendStream 发送 io.Reader 内容
+func TestContextSendStream(t *testing.T) {
+	resp := httptest.NewRecorder()
+	ctx := NewContext(resp, httptest.NewRequest(http.MethodGet, "/", nil), DefaultConfig())
+
+	if err := ctx.SendStream(strings.NewReader("streamed body"), 13); err != nil {
+		t.Fatalf("SendStream 失败: %v", err)
+	}
+	if resp.Body.String() != "streamed body" {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", resp.Body.String(), "streamed body")
+	}
+	if length := resp.Header().Get("Content-Length"); length != "13" {
+		t.Errorf("Content-Length 错误: 得到 %v, 期待 %v", length, "13")
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "application/octet-stream" {
+		t.Errorf("Content-Type 错误: 得到 %v, 期待 %v", ct, "application/octet-stream")
+	}
+}
+
+// 测试 NDJSON 与 JSON 文本序列编码
+func TestStreamEncoder(t *testing.T) {
+	resp := httptest.NewRecorder()
+	ctx := NewContext(resp, httptest.NewRequest(http.MethodGet, "/", nil), DefaultConfig())
+
+	stream := ctx.NDJSON(http.StatusOK)
+	for i := 1; i <= 2; i++ {
+		if err := stream.Encode(map[string]int{"id": i}); err != nil {
+			t.Fatalf("NDJSON 编码失败: %v", err)
+		}
+	}
+	if body := resp.Body.String(); body != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("NDJSON 内容错误: 得到 %q", body)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type 错误: 得到 %v, 期待 %v", ct, "application/x-ndjson")
+	}
+	if !resp.Flushed {
+		t.Error("NDJSON 未刷新响应")
+	}
+
+	resp = httptest.NewRecorder()
+	ctx = NewContext(resp, httptest.NewRequest(http.MethodGet, "/", nil), DefaultConfig())
+	if err := ctx.JSONSeq(http.StatusOK).Encode("kanggo"); err != nil {
+		t.Fatalf("JSON 文本序列编码失败: %v", err)
+	}
+	if body := resp.Body.String(); body != "\x1e\"kanggo\"\n" {
+		t.Errorf("JSON 文本序列内容错误: 得到 %q", body)
+	}
+}
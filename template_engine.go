@@ -1,9 +1,70 @@
 package kanggo
 
-import "net/http"
+import (
+	"errors"
+	"io"
+	"net/http"
 
-// TemplateEngine 定义模板引擎接口
+	"github.com/7836246/kanggo/constants"
+)
+
+var (
+	// ErrNoTemplateEngine 表示调用 Render 时既没有设置 Config.Views，也没有设置 Context.TemplateEngine
+	ErrNoTemplateEngine = errors.New("未配置模板引擎")
+	// ErrLayoutNotSupported 表示 Render 指定了布局，但模板引擎没有实现 LayoutRenderer
+	ErrLayoutNotSupported = errors.New("模板引擎不支持布局")
+)
+
+// TemplateEngine 定义模板引擎接口，Config.Views 与 Context.Render 通过它渲染页面
+//
+// 第三方模板引擎（例如 Jet、类 Django 语法的引擎或 Markdown 渲染）接入时需要遵守以下约定，
+// templatetest 包中的一致性测试会逐条检查：
+//
+//   - Load 可以重复调用，每次都重新加载全部模板；Run 启动时会自动调用一次
+//   - Render 可以被多个 goroutine 并发调用
+//   - Render 成功时设置 Content-Type 并写入完整的响应体，不调用 WriteHeader，状态码由 Context.Render 决定
+//   - Render 失败时返回错误且不写入任何响应头与响应体，错误由 Config.ErrorHandler 统一处理
+//   - 模板不存在时返回的错误满足 errors.Is(err, ErrTemplateNotFound)
+//
+// 只能渲染到 io.Writer 的引擎可以使用 TemplateEngineAdapter 包装，它会负责缓冲与写入响应
 type TemplateEngine interface {
 	Load() error                                                       // 加载模板文件
 	Render(w http.ResponseWriter, name string, data interface{}) error // 渲染模板
 }
+
+// LayoutRenderer 是支持按次指定布局的模板引擎需要实现的接口，layout 为空字符串表示不使用布局
+type LayoutRenderer interface {
+	RenderLayout(w http.ResponseWriter, name, layout string, data interface{}) error
+}
+
+// routerBinder 由需要路由器生成地址（模板中的 url 函数）的模板引擎实现
+type routerBinder interface {
+	bindRouter(router *Router)
+}
+
+// TemplateEngineAdapter 将只能渲染到 io.Writer 的第三方模板引擎包装为 TemplateEngine
+// 渲染结果先写入缓冲区，成功后才设置 Content-Type 并写入响应，满足 TemplateEngine 的约定
+type TemplateEngineAdapter struct {
+	ContentType string                                                 // 响应的 Content-Type，默认值 "text/html; charset=utf-8"
+	LoadFunc    func() error                                           // 加载模板，为 nil 时 Load 不做任何事
+	RenderFunc  func(w io.Writer, name string, data interface{}) error // 渲染模板，模板不存在时应返回包装了 ErrTemplateNotFound 的错误
+}
+
+// Load 调用 LoadFunc 加载模板
+func (a *TemplateEngineAdapter) Load() error {
+	if a.LoadFunc == nil {
+		return nil
+	}
+	return a.LoadFunc()
+}
+
+// Render 调用 RenderFunc 渲染到缓冲区，成功后写入响应
+func (a *TemplateEngineAdapter) Render(w http.ResponseWriter, name string, data interface{}) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = constants.MIMETextHTMLCharsetUTF8
+	}
+	return executeTemplate(w, contentType, func(buf io.Writer) error {
+		return a.RenderFunc(buf, name, data)
+	})
+}
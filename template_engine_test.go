@@ -1,10 +1,14 @@
 package kanggo
 
 import (
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHTMLTemplateEngine(t *testing.T) {
@@ -74,3 +78,185 @@ func TestHTMLTemplateEngine(t *testing.T) {
 		t.Errorf("渲染结果不正确: 得到 %s，期望 %s", recorder.Body.String(), expected)
 	}
 }
+
+// writeTemplates 在临时目录中写入模板文件
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("创建模板文件失败: %v", err)
+		}
+	}
+	return dir
+}
+
+// 测试递归加载、布局、局部模板与页面独立的模板集合
+func TestHTMLTemplateEngineLayout(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"layouts/main.html":  `<title>{{block "title" .}}默认标题{{end}}</title><main>{{- yield -}}</main>`,
+		"partials/nav.html":  `<nav>{{.User}}</nav>`,
+		"index.html":         `{{define "title"}}首页{{end}}{{template "partials/nav.html" .}}<p>index</p>`,
+		"users/show.html":    `<p>{{.User}}</p>`,
+		"users/profile.html": `{{define "title"}}资料{{end}}<p>profile</p>`,
+	})
+	engine := NewHTMLTemplateEngine(dir, "*.html").Layout("layouts/main.html")
+	if err := engine.Load(); err != nil {
+		t.Fatalf("加载 HTML 模板失败: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{"index.html", `<title>首页</title><main><nav>&lt;b&gt;</nav><p>index</p></main>`},
+		{"users/show.html", `<title>默认标题</title><main><p>&lt;b&gt;</p></main>`},
+		{"users/profile.html", `<title>资料</title><main><p>profile</p></main>`},
+		{"partials/nav.html", `<nav>&lt;b&gt;</nav>`},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		if err := engine.Render(recorder, tc.name, map[string]string{"User": "<b>"}); err != nil {
+			t.Fatalf("%s: 渲染失败: %v", tc.name, err)
+		}
+		if recorder.Body.String() != tc.expected {
+			t.Errorf("%s: 渲染结果不正确: 得到 %s，期望 %s", tc.name, recorder.Body.String(), tc.expected)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := engine.RenderLayout(recorder, "users/profile.html", "", nil); err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if recorder.Body.String() != `<p>profile</p>` {
+		t.Errorf("不使用布局时渲染结果不正确: 得到 %s", recorder.Body.String())
+	}
+}
+
+// 测试开发模式下模板文件变化后自动重新加载
+func TestHTMLTemplateEngineReload(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{"index.html": `v1`})
+	engine := NewHTMLTemplateEngine(dir, "*.html").Reload(true)
+	if err := engine.Load(); err != nil {
+		t.Fatalf("加载 HTML 模板失败: %v", err)
+	}
+
+	file := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(file, []byte(`v2`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 保证修改时间变化，避免文件系统时间精度导致的误判
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := engine.Render(recorder, "index.html", nil); err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if recorder.Body.String() != "v2" {
+		t.Errorf("模板未重新加载: 得到 %s，期望 v2", recorder.Body.String())
+	}
+}
+
+// 测试渲染失败时不输出残缺的页面，并返回带有位置信息的错误
+func TestHTMLTemplateEngineRenderError(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"broken.html": "<p>开始</p>\n{{.User.Name}}\n<p>结束</p>",
+	})
+	engine := NewHTMLTemplateEngine(dir, "*.html")
+	if err := engine.Load(); err != nil {
+		t.Fatalf("加载 HTML 模板失败: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	err := engine.Render(recorder, "broken.html", map[string]interface{}{"User": nil})
+	var te *TemplateError
+	if !errors.As(err, &te) {
+		t.Fatalf("期待 *TemplateError, 得到 %v", err)
+	}
+	if te.Name != "broken.html" || te.Line != 2 || te.Debug {
+		t.Errorf("错误信息不正确: 名称 %s, 行号 %d, 开发模式 %v", te.Name, te.Line, te.Debug)
+	}
+	if recorder.Body.Len() != 0 || recorder.Header().Get("Content-Type") != "" {
+		t.Errorf("渲染失败时不应写入响应: %q", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	if err := engine.Render(recorder, "missing.html", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("期待 ErrTemplateNotFound, 得到 %v", err)
+	}
+	if recorder.Body.Len() != 0 {
+		t.Errorf("模板不存在时不应写入响应: %q", recorder.Body.String())
+	}
+}
+
+// 测试开发模式下的模板错误页面，以及生产模式下不泄露模板信息
+func TestTemplateErrorPage(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"broken.html": "<p>开始</p>\n{{.User.Name}}",
+	})
+
+	for _, dev := range []bool{true, false} {
+		app := New(Config{Views: NewHTMLTemplateEngine(dir, "*.html").Reload(dev)})
+		if err := app.LoadViews(); err != nil {
+			t.Fatalf("加载模板失败: %v", err)
+		}
+		app.GET("/broken", func(ctx *Context) error {
+			return ctx.Render(http.StatusOK, "broken.html", map[string]interface{}{"User": nil, "Secret": "s3cr3t"})
+		})
+
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/broken", nil))
+		if resp.Code != http.StatusInternalServerError {
+			t.Errorf("开发模式 %v: 状态码错误: 得到 %v, 期待 %v", dev, resp.Code, http.StatusInternalServerError)
+		}
+		body := resp.Body.String()
+		for _, want := range []string{"broken.html:2", "{{.User.Name}}", "s3cr3t"} {
+			if strings.Contains(body, want) != dev {
+				t.Errorf("开发模式 %v: 页面是否包含 %q 不正确: %s", dev, want, body)
+			}
+		}
+		if strings.Contains(body, "开始") && !dev {
+			t.Errorf("生产模式下输出了残缺的页面: %s", body)
+		}
+	}
+}
+
+// 测试文本模板引擎不进行 HTML 转义，并可以在 HTTP 请求之外渲染
+func TestTextTemplateEngine(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"welcome.txt": `你好 {{.Name}}，订单 {{.Order}} 已发货 & 正在派送 {{json .Items}}`,
+	})
+	engine := NewTextTemplateEngine(dir, "*.txt")
+	if err := engine.Load(); err != nil {
+		t.Fatalf("加载文本模板失败: %v", err)
+	}
+	data := map[string]interface{}{"Name": "<康>", "Order": "A&B", "Items": []string{"<书>"}}
+	expected := `你好 <康>，订单 A&B 已发货 & 正在派送 ["<书>"]`
+
+	out, err := engine.RenderToString("welcome.txt", data)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if out != expected {
+		t.Errorf("渲染结果不正确: 得到 %s，期望 %s", out, expected)
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := engine.Render(recorder, "welcome.txt", data); err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if recorder.Body.String() != expected || recorder.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("响应不正确: %s, %s", recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+
+	if _, err := engine.RenderToString("missing.txt", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("期待 ErrTemplateNotFound, 得到 %v", err)
+	}
+}
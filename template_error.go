@@ -0,0 +1,156 @@
+package kanggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/7836246/kanggo/constants"
+)
+
+// ErrTemplateNotFound 表示要渲染的模板不存在
+var ErrTemplateNotFound = errors.New("模板未找到")
+
+// templateLocation 匹配标准库模板错误中的位置信息，例如 "template: users/show.html:3:12: ..."
+var templateLocation = regexp.MustCompile(`^template: (.+?):(\d+):`)
+
+// templateBufferPool 渲染模板使用的缓冲区池，渲染成功后才写入响应
+var templateBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// maxPooledTemplateBuffer 超过此容量的缓冲区不放回池中，避免偶尔的大页面长期占用内存
+const maxPooledTemplateBuffer = 1 << 20
+
+// TemplateError 是模板加载或渲染失败时返回的错误
+type TemplateError struct {
+	Name   string      // 出错的模板名称，能从错误信息中解析时为实际出错的模板（例如局部模板）
+	Line   int         // 出错的行号，无法确定时为 0
+	Source string      // 出错模板的源码，用于在错误页面中显示上下文
+	Data   interface{} // 渲染时使用的模板数据
+	Err    error       // 原始错误
+	Debug  bool        // 是否由开发模式（Reload）的模板引擎返回，DefaultErrorHandler 据此显示错误页面
+}
+
+// Error 实现 error 接口
+func (e *TemplateError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("模板 %s 第 %d 行: %v", e.Name, e.Line, e.Err)
+	}
+	return fmt.Sprintf("模板 %s: %v", e.Name, e.Err)
+}
+
+// Unwrap 返回原始错误，支持 errors.Is(err, ErrTemplateNotFound)
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// newTemplateError 创建 TemplateError，并从错误信息中解析实际出错的模板与行号
+// sources 为模板名称到源码的映射，可以为 nil
+func newTemplateError(name string, data interface{}, err error, sources map[string]string, debug bool) *TemplateError {
+	te := &TemplateError{Name: name, Data: data, Err: err, Debug: debug}
+	if m := templateLocation.FindStringSubmatch(err.Error()); m != nil {
+		te.Name = m[1]
+		te.Line, _ = strconv.Atoi(m[2])
+	}
+	te.Source = sources[te.Name]
+	return te
+}
+
+// sourceLine 是错误页面中显示的一行源码
+type sourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+// debugPageTemplate 开发模式下的模板错误页面
+var debugPageTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>模板渲染错误</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;margin:2em;color:#222}
+h1{color:#c0392b}
+pre{background:#f6f8fa;padding:1em;overflow:auto}
+.current{background:#fdd;display:block}
+</style>
+</head>
+<body>
+<h1>模板渲染错误</h1>
+<p><strong>{{.Name}}{{if .Line}}:{{.Line}}{{end}}</strong></p>
+<pre>{{.Message}}</pre>
+{{- if .Lines}}
+<h2>源码</h2>
+<pre>{{range .Lines}}<span{{if .Current}} class="current"{{end}}>{{printf "%4d" .Number}}  {{.Text}}</span>
+{{end}}</pre>
+{{- end}}
+<h2>数据</h2>
+<pre>{{.Data}}</pre>
+</body>
+</html>
+`))
+
+// WriteDebugPage 以 500 状态码输出包含模板名称、出错行附近的源码与模板数据的错误页面
+// 页面会暴露模板源码与数据，只应在开发环境中使用
+func (e *TemplateError) WriteDebugPage(w http.ResponseWriter) error {
+	page := struct {
+		Name    string
+		Line    int
+		Message string
+		Lines   []sourceLine
+		Data    string
+	}{Name: e.Name, Line: e.Line, Message: e.Err.Error(), Data: dumpTemplateData(e.Data)}
+
+	if e.Source != "" && e.Line > 0 {
+		lines := strings.Split(e.Source, "\n")
+		from, to := max(e.Line-4, 0), min(e.Line+3, len(lines))
+		for i := from; i < to; i++ {
+			page.Lines = append(page.Lines, sourceLine{Number: i + 1, Text: lines[i], Current: i+1 == e.Line})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := debugPageTemplate.Execute(&buf, page); err != nil {
+		return err
+	}
+	w.Header().Set(constants.HeaderContentType, constants.MIMETextHTMLCharsetUTF8)
+	w.WriteHeader(http.StatusInternalServerError)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// dumpTemplateData 将模板数据格式化为便于阅读的文本，无法编码为 JSON 时使用 Go 语法表示
+func dumpTemplateData(data interface{}) string {
+	if out, err := json.MarshalIndent(data, "", "  "); err == nil {
+		return string(out)
+	}
+	return fmt.Sprintf("%#v", data)
+}
+
+// executeTemplate 将模板渲染到缓冲区，成功后才设置 Content-Type 并写入响应，失败时不会写入任何内容
+func executeTemplate(w http.ResponseWriter, contentType string, execute func(io.Writer) error) error {
+	buf := templateBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledTemplateBuffer {
+			buf.Reset()
+			templateBufferPool.Put(buf)
+		}
+	}()
+
+	if err := execute(buf); err != nil {
+		return err
+	}
+	w.Header().Set(constants.HeaderContentType, contentType)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
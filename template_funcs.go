@@ -0,0 +1,194 @@
+package kanggo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FuncMap 是模板函数表，html/template.FuncMap 与 text/template.FuncMap 都可以直接传入
+type FuncMap = map[string]interface{}
+
+// Translator 根据语言与键返回翻译后的文本，供模板中的 t 函数使用
+type Translator func(lang, key string, args ...interface{}) string
+
+// 模板数据中约定的键，请求相关的内置函数从这些键读取数据
+const (
+	TemplateKeyCSRF = "csrf" // CSRF 令牌，供 csrf 与 csrfField 使用
+	TemplateKeyLang = "lang" // 当前语言，供 t 使用
+)
+
+// DefaultDateLayout date 函数未指定格式时使用的时间格式
+const DefaultDateLayout = "2006-01-02 15:04:05"
+
+// errNoTemplateRouter 表示模板中调用了 url 函数，但模板引擎没有关联路由
+var errNoTemplateRouter = errors.New("模板引擎未设置路由，无法使用 url 函数")
+
+// templateHelpers 保存模板引擎的自定义函数与内置函数所需的配置
+//
+// 内置函数：
+//
+//	date      {{date .CreatedAt}} 或 {{date .CreatedAt "2006-01-02"}}，格式化时间
+//	json      {{json .}}，编码为 JSON，可直接嵌入 <script> 中
+//	asset     {{asset "css/app.css"}}，返回带内容指纹的静态资源地址，例如 /static/css/app.css?v=1a2b3c4d
+//	url       {{url "user.show" "id" .ID}}，根据路由名称与参数生成地址
+//	csrf      {{csrf .}}，读取模板数据中 "csrf" 键的令牌
+//	csrfField {{csrfField .}}，输出携带 CSRF 令牌的隐藏表单字段
+//	t         {{t . "greeting" .Name}}，按模板数据中 "lang" 键的语言翻译文本
+type templateHelpers struct {
+	funcs       FuncMap
+	assets      fs.FS
+	assetPrefix string
+	assetHashes sync.Map // 资源路径 -> assetHash
+	router      *Router
+	translator  Translator
+}
+
+// assetHash 缓存的资源指纹，文件大小或修改时间变化后重新计算
+type assetHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// addFuncs 合并自定义函数，同名时覆盖内置函数
+func (h *templateHelpers) addFuncs(funcs FuncMap) {
+	if h.funcs == nil {
+		h.funcs = make(FuncMap, len(funcs))
+	}
+	for name, fn := range funcs {
+		h.funcs[name] = fn
+	}
+}
+
+// funcMap 返回内置函数与自定义函数合并后的函数表
+// html 为 true 时 json 与 csrfField 返回无需再次转义的 template.JS 与 template.HTML
+func (h *templateHelpers) funcMap(html bool) FuncMap {
+	funcs := FuncMap{
+		"date":  formatDate,
+		"asset": h.assetURL,
+		"url":   h.routeURL,
+		"csrf":  csrfToken,
+		"t":     h.translate,
+	}
+	if html {
+		funcs["json"] = func(v interface{}) (template.JS, error) {
+			data, err := json.Marshal(v)
+			return template.JS(data), err
+		}
+		funcs["csrfField"] = func(data interface{}) template.HTML {
+			return template.HTML(csrfField(data))
+		}
+	} else {
+		funcs["json"] = func(v interface{}) (string, error) {
+			// 纯文本输出不需要把 <、>、& 转义为 \u003c 等形式
+			var b strings.Builder
+			enc := json.NewEncoder(&b)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(v); err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(b.String(), "\n"), nil
+		}
+		funcs["csrfField"] = csrfField
+	}
+	for name, fn := range h.funcs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// formatDate 按 layout 格式化时间，零值时间返回空字符串
+func formatDate(t time.Time, layout ...string) string {
+	if t.IsZero() {
+		return ""
+	}
+	if len(layout) > 0 && layout[0] != "" {
+		return t.Format(layout[0])
+	}
+	return t.Format(DefaultDateLayout)
+}
+
+// assetURL 返回带内容指纹的资源地址，未设置资源目录时只拼接前缀
+func (h *templateHelpers) assetURL(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	url := strings.TrimSuffix(h.assetPrefix, "/") + "/" + name
+	if h.assets == nil {
+		return url, nil
+	}
+
+	info, err := fs.Stat(h.assets, name)
+	if err != nil {
+		return "", err
+	}
+	if cached, ok := h.assetHashes.Load(name); ok {
+		if c := cached.(assetHash); c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+			return url + "?v=" + c.hash, nil
+		}
+	}
+	data, err := fs.ReadFile(h.assets, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:4])
+	h.assetHashes.Store(name, assetHash{size: info.Size(), modTime: info.ModTime(), hash: hash})
+	return url + "?v=" + hash, nil
+}
+
+// routeURL 根据路由名称与成对的参数生成地址，例如 url "user.show" "id" 1
+func (h *templateHelpers) routeURL(name string, pairs ...interface{}) (string, error) {
+	if h.router == nil {
+		return "", errNoTemplateRouter
+	}
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("url 函数的参数必须成对出现: %v", pairs)
+	}
+	params := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		params[fmt.Sprint(pairs[i])] = fmt.Sprint(pairs[i+1])
+	}
+	return h.router.URL(name, params)
+}
+
+// translate 使用 Translator 翻译文本，未设置 Translator 时原样返回 key
+func (h *templateHelpers) translate(data interface{}, key string, args ...interface{}) string {
+	if h.translator == nil {
+		return key
+	}
+	lang, _ := templateValue(data, TemplateKeyLang).(string)
+	return h.translator(lang, key, args...)
+}
+
+// csrfToken 读取模板数据中的 CSRF 令牌
+func csrfToken(data interface{}) string {
+	token, _ := templateValue(data, TemplateKeyCSRF).(string)
+	return token
+}
+
+// csrfField 返回携带 CSRF 令牌的隐藏表单字段
+func csrfField(data interface{}) string {
+	return `<input type="hidden" name="_csrf" value="` + template.HTMLEscapeString(csrfToken(data)) + `">`
+}
+
+// templateValue 从模板数据中读取 key 对应的值，支持 map 与 *Context（读取请求局部变量）
+func templateValue(data interface{}, key string) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return v[key]
+	case map[string]string:
+		return v[key]
+	case *Context:
+		value, _ := v.Get(key)
+		return value
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package kanggo
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// 测试自定义函数与内置模板函数
+func TestTemplateFuncs(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"funcs.html": `{{upper "kanggo"}}|{{date .Time}}|{{date .Time "2006/01/02"}}|` +
+			`<script>var data = {{json .Data}};</script>|{{asset "css/app.css"}}|` +
+			`{{url "user.show" "id" 7}}|{{csrf .}}|{{csrfField .}}|{{t . "hello" "世界"}}`,
+	})
+	assets := fstest.MapFS{"css/app.css": {Data: []byte("body{}")}}
+
+	app := New(Config{})
+	app.GET("/users/:id", func(ctx *Context) error { return nil }).Name("user.show")
+
+	engine := NewHTMLTemplateEngine(dir, "*.html").
+		Funcs(FuncMap{"upper": strings.ToUpper}).
+		Assets("/static", assets).
+		Router(app.Router).
+		Translate(func(lang, key string, args ...interface{}) string {
+			return lang + ":" + key + ":" + args[0].(string)
+		})
+	if err := engine.Load(); err != nil {
+		t.Fatalf("加载 HTML 模板失败: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	data := map[string]interface{}{
+		"Time": time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+		"Data": map[string]string{"name": "</script>"},
+		"csrf": `tok"en`,
+		"lang": "zh",
+	}
+	if err := engine.Render(recorder, "funcs.html", data); err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+
+	expected := []string{
+		"KANGGO",
+		"2024-05-01 08:30:00",
+		"2024/05/01",
+		`var data = {"name":"\u003c/script\u003e"};`,
+		"/static/css/app.css?v=",
+		"/users/7",
+		"tok&#34;en",
+		`<input type="hidden" name="_csrf" value="tok&#34;en">`,
+		"zh:hello:世界",
+	}
+	parts := strings.Split(recorder.Body.String(), "|")
+	if len(parts) != len(expected) {
+		t.Fatalf("渲染结果不正确: %s", recorder.Body.String())
+	}
+	for i, want := range expected {
+		if !strings.Contains(parts[i], want) {
+			t.Errorf("第 %d 项不正确: 得到 %s，期望包含 %s", i, parts[i], want)
+		}
+	}
+}
+
+// 测试资源指纹随文件内容变化
+func TestTemplateAssetFingerprint(t *testing.T) {
+	assets := fstest.MapFS{"app.js": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}}
+	helpers := &templateHelpers{assets: assets, assetPrefix: "/static/"}
+
+	first, err := helpers.assetURL("/app.js")
+	if err != nil {
+		t.Fatalf("生成资源地址失败: %v", err)
+	}
+	assets["app.js"] = &fstest.MapFile{Data: []byte("v2"), ModTime: time.Unix(2, 0)}
+	second, err := helpers.assetURL("app.js")
+	if err != nil {
+		t.Fatalf("生成资源地址失败: %v", err)
+	}
+	if !strings.HasPrefix(first, "/static/app.js?v=") || first == second {
+		t.Errorf("资源指纹不正确: %s, %s", first, second)
+	}
+	if _, err := helpers.assetURL("missing.js"); err == nil {
+		t.Error("不存在的资源应返回错误")
+	}
+}
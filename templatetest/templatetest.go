@@ -0,0 +1,128 @@
+// Package templatetest 提供 kanggo.TemplateEngine 的一致性测试，
+// 内置模板引擎与第三方模板引擎的适配器都可以用它检查是否符合 TemplateEngine 的约定。
+package templatetest
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/7836246/kanggo"
+)
+
+// Suite 描述被测试的模板引擎，模板源码使用引擎自身的语法编写
+type Suite struct {
+	Ext    string                                 // 模板文件的扩展名，例如 ".html"
+	Hello  string                                 // 渲染数据 {"Name": "KangGo"} 时输出 "Hello, KangGo" 的模板源码
+	Broken string                                 // 先输出部分内容、随后执行失败的模板源码
+	New    func(fsys fs.FS) kanggo.TemplateEngine // 创建从 fsys 根目录加载模板的引擎
+}
+
+// Run 依次运行所有一致性测试，模板以 "hello"+Ext 与 "broken"+Ext 为名放在文件系统根目录
+func Run(t *testing.T, s Suite) {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"hello" + s.Ext:  {Data: []byte(s.Hello)},
+		"broken" + s.Ext: {Data: []byte(s.Broken)},
+	}
+	data := map[string]interface{}{"Name": "KangGo"}
+
+	newEngine := func(t *testing.T) kanggo.TemplateEngine {
+		t.Helper()
+		engine := s.New(fsys)
+		if err := engine.Load(); err != nil {
+			t.Fatalf("加载模板失败: %v", err)
+		}
+		return engine
+	}
+
+	t.Run("Load", func(t *testing.T) {
+		engine := newEngine(t)
+		if err := engine.Load(); err != nil {
+			t.Errorf("重复加载模板失败: %v", err)
+		}
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		w := newRecorder()
+		if err := newEngine(t).Render(w, "hello"+s.Ext, data); err != nil {
+			t.Fatalf("渲染失败: %v", err)
+		}
+		if w.body.String() != "Hello, KangGo" {
+			t.Errorf("渲染结果不正确: 得到 %q, 期待 %q", w.body.String(), "Hello, KangGo")
+		}
+		if w.header.Get("Content-Type") == "" {
+			t.Error("渲染成功时应设置 Content-Type")
+		}
+		if w.code != 0 {
+			t.Errorf("模板引擎不应调用 WriteHeader, 得到状态码 %d", w.code)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		w := newRecorder()
+		err := newEngine(t).Render(w, "missing"+s.Ext, data)
+		if !errors.Is(err, kanggo.ErrTemplateNotFound) {
+			t.Errorf("期待 kanggo.ErrTemplateNotFound, 得到 %v", err)
+		}
+		w.assertUntouched(t)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		w := newRecorder()
+		if err := newEngine(t).Render(w, "broken"+s.Ext, data); err == nil {
+			t.Error("模板执行失败时应返回错误")
+		}
+		w.assertUntouched(t)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		engine := newEngine(t)
+		var wg sync.WaitGroup
+		errs := make(chan error, 16)
+		for i := 0; i < cap(errs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := newRecorder()
+				if err := engine.Render(w, "hello"+s.Ext, data); err != nil {
+					errs <- err
+				} else if w.body.String() != "Hello, KangGo" {
+					errs <- errors.New("并发渲染结果不正确: " + w.body.String())
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+}
+
+// recorder 记录模板引擎对 ResponseWriter 的所有操作
+type recorder struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header)}
+}
+
+func (r *recorder) Header() http.Header         { return r.header }
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+func (r *recorder) WriteHeader(code int)        { r.code = code }
+
+// assertUntouched 检查渲染失败时没有写入任何响应头、状态码与响应体
+func (r *recorder) assertUntouched(t *testing.T) {
+	t.Helper()
+	if r.code != 0 || r.body.Len() != 0 || len(r.header) != 0 {
+		t.Errorf("渲染失败时不应写入响应: 状态码 %d, 响应头 %v, 内容 %q", r.code, r.header, r.body.String())
+	}
+}
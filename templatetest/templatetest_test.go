@@ -0,0 +1,85 @@
+package templatetest
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/7836246/kanggo"
+)
+
+// 测试内置的 HTML 模板引擎
+func TestHTMLTemplateEngine(t *testing.T) {
+	Run(t, Suite{
+		Ext:    ".html",
+		Hello:  `Hello, {{.Name}}`,
+		Broken: `Hello, {{index .Name 99}}`,
+		New: func(fsys fs.FS) kanggo.TemplateEngine {
+			return kanggo.NewHTMLTemplateEngineFS(fsys, "*.html")
+		},
+	})
+}
+
+// 测试内置的文本模板引擎
+func TestTextTemplateEngine(t *testing.T) {
+	Run(t, Suite{
+		Ext:    ".txt",
+		Hello:  `Hello, {{.Name}}`,
+		Broken: `Hello, {{index .Name 99}}`,
+		New: func(fsys fs.FS) kanggo.TemplateEngine {
+			return kanggo.NewTextTemplateEngineFS(fsys, "*.txt")
+		},
+	})
+}
+
+// 测试通过 TemplateEngineAdapter 接入的第三方模板引擎，这里用一个以 $变量 替换内容的简单引擎代替
+func TestTemplateEngineAdapter(t *testing.T) {
+	Run(t, Suite{
+		Ext:    ".tpl",
+		Hello:  `Hello, $Name`,
+		Broken: `Hello, $Missing`,
+		New: func(fsys fs.FS) kanggo.TemplateEngine {
+			templates := map[string]string{}
+			return &kanggo.TemplateEngineAdapter{
+				ContentType: "text/plain",
+				LoadFunc: func() error {
+					matches, err := fs.Glob(fsys, "*.tpl")
+					if err != nil {
+						return err
+					}
+					for _, name := range matches {
+						data, err := fs.ReadFile(fsys, name)
+						if err != nil {
+							return err
+						}
+						templates[name] = string(data)
+					}
+					return nil
+				},
+				RenderFunc: func(w io.Writer, name string, data interface{}) error {
+					source, ok := templates[name]
+					if !ok {
+						return fmt.Errorf("%w: %s", kanggo.ErrTemplateNotFound, name)
+					}
+					values := data.(map[string]interface{})
+					var missing []string
+					out := os.Expand(source, func(key string) string {
+						value, ok := values[key]
+						if !ok {
+							missing = append(missing, key)
+						}
+						return fmt.Sprint(value)
+					})
+					if len(missing) > 0 {
+						return fmt.Errorf("变量不存在: %s", strings.Join(missing, ", "))
+					}
+					_, err := io.WriteString(w, out)
+					return err
+				},
+			}
+		},
+	})
+}
@@ -2,23 +2,77 @@ package kanggo
 
 import (
 	"github.com/7836246/kanggo/constants"
-	"html/template"
+	"io"
+	"io/fs"
 	"net/http"
-	"path/filepath"
+	"os"
+	"strings"
 	"sync"
+	"text/template"
 )
 
-// TextTemplateEngine 使用 Go 标准库 text/template 的模板引擎
+// TextTemplateEngine 使用 Go 标准库 text/template 的模板引擎，输出内容不做 HTML 转义
+// 适用于纯文本响应与邮件正文，RenderToString 与 RenderToWriter 可以在 HTTP 请求之外使用
 type TextTemplateEngine struct {
 	templates *template.Template
+	helpers   templateHelpers
 	lock      sync.RWMutex
-	dir       string
+	fsys      fs.FS
 	pattern   string
 }
 
-// NewTextTemplateEngine 创建一个新的 TextTemplateEngine 实例
+// NewTextTemplateEngine 创建一个从磁盘目录 dir 加载模板的 TextTemplateEngine 实例
+// pattern 用于匹配文件，例如 "*.txt"，模板按文件名命名
 func NewTextTemplateEngine(dir, pattern string) *TextTemplateEngine {
-	return &TextTemplateEngine{dir: dir, pattern: pattern}
+	return NewTextTemplateEngineFS(os.DirFS(dir), pattern)
+}
+
+// NewTextTemplateEngineFS 创建一个从 fsys 加载模板的 TextTemplateEngine 实例，可直接使用 embed.FS
+func NewTextTemplateEngineFS(fsys fs.FS, pattern string) *TextTemplateEngine {
+	return &TextTemplateEngine{fsys: fsys, pattern: pattern}
+}
+
+// Funcs 添加自定义模板函数，同名时覆盖内置函数，必须在 Load 之前调用
+func (e *TextTemplateEngine) Funcs(funcs FuncMap) *TextTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.addFuncs(funcs)
+	return e
+}
+
+// Assets 设置 asset 函数使用的资源目录与地址前缀，生成的地址带有文件内容的指纹
+// 例如 Assets("/static", os.DirFS("public")) 后 {{asset "app.css"}} 输出 /static/app.css?v=1a2b3c4d
+func (e *TextTemplateEngine) Assets(prefix string, fsys fs.FS) *TextTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.assetPrefix = prefix
+	e.helpers.assets = fsys
+	return e
+}
+
+// Router 设置 url 函数查找命名路由时使用的路由器
+func (e *TextTemplateEngine) Router(router *Router) *TextTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.router = router
+	return e
+}
+
+// bindRouter 在未通过 Router 设置路由器时使用应用的路由器
+func (e *TextTemplateEngine) bindRouter(router *Router) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.helpers.router == nil {
+		e.helpers.router = router
+	}
+}
+
+// Translate 设置 t 函数使用的翻译函数
+func (e *TextTemplateEngine) Translate(translator Translator) *TextTemplateEngine {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.helpers.translator = translator
+	return e
 }
 
 // Load 加载模板文件
@@ -26,7 +80,7 @@ func (e *TextTemplateEngine) Load() error {
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
-	tmpl, err := template.ParseGlob(filepath.Join(e.dir, e.pattern))
+	tmpl, err := template.New("").Funcs(e.helpers.funcMap(false)).ParseFS(e.fsys, e.pattern)
 	if err != nil {
 		return err
 	}
@@ -34,17 +88,37 @@ func (e *TextTemplateEngine) Load() error {
 	return nil
 }
 
-// Render 渲染模板
+// Render 渲染模板并以 text/plain; charset=utf-8 响应，模板先渲染到缓冲区，失败时不写入任何内容
 func (e *TextTemplateEngine) Render(w http.ResponseWriter, name string, data interface{}) error {
+	return executeTemplate(w, constants.MIMETextPlainCharsetUTF8, func(buf io.Writer) error {
+		return e.RenderToWriter(buf, name, data)
+	})
+}
+
+// RenderToString 渲染模板并返回结果，例如生成纯文本邮件正文
+func (e *TextTemplateEngine) RenderToString(name string, data interface{}) (string, error) {
+	var b strings.Builder
+	if err := e.RenderToWriter(&b, name, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RenderToWriter 将模板渲染到任意 io.Writer，失败时返回 *TemplateError
+// 出错时 w 中可能已经写入了部分内容，需要完整结果时使用 RenderToString
+func (e *TextTemplateEngine) RenderToWriter(w io.Writer, name string, data interface{}) error {
 	e.lock.RLock()
 	defer e.lock.RUnlock()
 
-	tmpl := e.templates.Lookup(name)
+	var tmpl *template.Template
+	if e.templates != nil {
+		tmpl = e.templates.Lookup(name)
+	}
 	if tmpl == nil {
-		http.Error(w, "模板未找到", http.StatusInternalServerError)
-		return nil
+		return newTemplateError(name, data, ErrTemplateNotFound, nil, false)
 	}
-
-	w.Header().Set("Content-Type", constants.MIMETextPlain)
-	return tmpl.Execute(w, data)
+	if err := tmpl.Execute(w, data); err != nil {
+		return newTemplateError(name, data, err, nil, false)
+	}
+	return nil
 }
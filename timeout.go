@@ -0,0 +1,210 @@
+package kanggo
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Context 返回请求的 context.Context
+// 客户端断开连接或超时后 Done() 会被关闭，处理函数应据此停止耗时操作
+func (c *Context) Context() context.Context {
+	return c.Request.Context()
+}
+
+// Timeout 为单个路由设置超时时间，超时后取消请求的 context.Context 并通过错误处理函数返回错误
+// timeoutErr 默认为 ErrServiceUnavailable（503），也可以传入 ErrGatewayTimeout 等错误
+// handler 在独立的 goroutine 中执行：超时后它仍会继续运行直到返回，但之后的写入会被丢弃并返回 http.ErrHandlerTimeout，
+// 之后发生的 panic 会连同堆栈通过 log 包记录，不会导致进程退出
+// 如果超时前 handler 已经开始写入响应，则无法再返回错误，响应会在超时时结束；客户端在超时前断开时返回 nil
+func Timeout(handler HandlerFunc, timeout time.Duration, timeoutErr ...error) HandlerFunc {
+	if timeout <= 0 {
+		return handler
+	}
+	var errTimeout error = ErrServiceUnavailable
+	if len(timeoutErr) > 0 && timeoutErr[0] != nil {
+		errTimeout = timeoutErr[0]
+	}
+
+	return func(c *Context) error {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		tw := newTimeoutWriter(c.Writer, ctx)
+		hc := c.detach(tw, c.Request.WithContext(ctx))
+
+		done := make(chan error, 1)
+		panicked := make(chan handlerPanic, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- handlerPanic{value: p, stack: debug.Stack()}
+				}
+			}()
+			done <- handler(hc)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p.value) // 交给外层的 recovery 中间件处理
+		case err := <-done:
+			if ctx.Err() == nil {
+				// 处理函数没有写入状态码时，它设置的响应头也要交给之后的错误处理函数或默认响应
+				tw.finish()
+				if hc.locals != nil {
+					c.locals = hc.locals // 保留处理函数中设置的局部变量
+					c.bindLocals()
+				}
+				return err
+			}
+		case <-ctx.Done():
+			// 处理函数仍在运行，之后发生的 panic 已无法交给 recovery 中间件，只能记录下来
+			go func() {
+				select {
+				case p := <-panicked:
+					log.Printf("kanggo: 超时后处理函数发生 panic: %v\n%s", p.value, p.stack)
+				case <-done:
+				}
+			}()
+		}
+
+		// 已超时或客户端已断开
+		if tw.timeout() {
+			return nil // 响应已经开始发送，只能就此结束
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return errTimeout
+		}
+		return nil // 客户端已断开，不再需要错误响应，也不应被记录为 500
+	}
+}
+
+// handlerPanic 记录处理函数 goroutine 中发生的 panic 及其堆栈
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// detach 复制一个独立于对象池的 Context，供超时后仍可能继续运行的处理函数使用
+func (c *Context) detach(w http.ResponseWriter, req *http.Request) *Context {
+	hc := &Context{contextConfig: c.contextConfig}
+	hc.writer.ctx = hc
+	hc.reset(w, req)
+	hc.TemplateEngine = c.TemplateEngine
+	hc.Params = append(hc.Params, c.Params...)
+	hc.path = c.path
+	if c.locals != nil {
+		hc.locals = &localStore{values: make(map[string]interface{}, len(c.locals.values))}
+		for key, value := range c.locals.values {
+			hc.locals.values[key] = value
+		}
+		hc.bindLocals()
+	}
+	return hc
+}
+
+// timeoutWriter 在超时后拒绝处理函数继续写入，防止与错误响应交错
+// 处理函数使用独立的响应头，直到真正写入状态码时才复制到底层的 ResponseWriter
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	ctx         context.Context
+	header      http.Header
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+// newTimeoutWriter 创建 timeoutWriter，初始响应头复制自 w
+func newTimeoutWriter(w http.ResponseWriter, ctx context.Context) *timeoutWriter {
+	return &timeoutWriter{w: w, ctx: ctx, header: w.Header().Clone()}
+}
+
+// Header 返回处理函数使用的响应头
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader 在未超时时写入状态码
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+// Write 在未超时时写入响应体，超时后返回 http.ErrHandlerTimeout
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(p)
+}
+
+// FlushError 在未超时时刷新响应，供 http.ResponseController 使用
+func (tw *timeoutWriter) FlushError() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return http.NewResponseController(tw.w).Flush()
+}
+
+// Flush 实现 http.Flusher
+func (tw *timeoutWriter) Flush() {
+	_ = tw.FlushError()
+}
+
+// writeHeaderLocked 将响应头复制到底层 ResponseWriter 并写入状态码，调用方必须持有锁
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.copyHeaderLocked()
+	tw.w.WriteHeader(code)
+	tw.wroteHeader = true
+}
+
+// copyHeaderLocked 用处理函数的响应头替换底层 ResponseWriter 的响应头，调用方必须持有锁
+func (tw *timeoutWriter) copyHeaderLocked() {
+	dst := tw.w.Header()
+	clear(dst)
+	for key, values := range tw.header.Clone() {
+		dst[key] = values
+	}
+}
+
+// finish 在处理函数按时返回后调用，尚未写入状态码时将响应头复制到底层 ResponseWriter
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.wroteHeader && !tw.expired() {
+		tw.copyHeaderLocked()
+	}
+}
+
+// expired 判断是否已经超时，context 到期后即使尚未调用 timeout 也拒绝写入，调用方必须持有锁
+func (tw *timeoutWriter) expired() bool {
+	if !tw.timedOut && tw.ctx.Err() != nil {
+		tw.timedOut = true
+	}
+	return tw.timedOut
+}
+
+// timeout 标记为已超时，返回超时前是否已经开始写入响应
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+	return tw.wroteHeader
+}
@@ -0,0 +1,177 @@
+package kanggo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试路由超时：取消 context 并通过错误处理函数返回 503，超时后的写入被丢弃
+func TestRouteTimeout(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	lateWrite := make(chan error, 1)
+	router.Handle(http.MethodGet, "/slow", Timeout(func(ctx *Context) error {
+		<-ctx.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := ctx.Writer.Write([]byte("too late"))
+		lateWrite <- err
+		return nil
+	}, 20*time.Millisecond))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusServiceUnavailable)
+	}
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("超时后写入应返回 http.ErrHandlerTimeout, 得到 %v", err)
+	}
+	if body := resp.Body.String(); body != "Service Unavailable\n" {
+		t.Errorf("响应内容错误: 得到 %q", body)
+	}
+}
+
+// 测试未超时的处理函数与自定义超时错误
+func TestRouteTimeoutFinished(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	router.Handle(http.MethodGet, "/fast/:id", Timeout(func(ctx *Context) error {
+		ctx.Writer.Header().Set("X-Id", ctx.Param("id"))
+		ctx.Set("handled", true)
+		return ctx.SendString("ok")
+	}, time.Second))
+	router.Handle(http.MethodGet, "/gateway", Timeout(func(ctx *Context) error {
+		<-ctx.Context().Done()
+		return nil
+	}, time.Millisecond, ErrGatewayTimeout))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/fast/42", nil))
+	if resp.Code != http.StatusOK || resp.Body.String() != "ok" {
+		t.Errorf("响应错误: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+	}
+	if id := resp.Header().Get("X-Id"); id != "42" {
+		t.Errorf("响应头错误: 得到 %v, 期待 %v", id, "42")
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/gateway", nil))
+	if resp.Code != http.StatusGatewayTimeout {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusGatewayTimeout)
+	}
+}
+
+// 测试超时前客户端断开连接时不返回错误，避免被错误处理函数当作 500
+func TestRouteTimeoutClientGone(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	router.Handle(http.MethodGet, "/slow", Timeout(func(ctx *Context) error {
+		<-ctx.Context().Done()
+		return nil
+	}, time.Second))
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(reqCtx)
+	resp := httptest.NewRecorder()
+	time.AfterFunc(10*time.Millisecond, cancel)
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK || resp.Body.Len() != 0 {
+		t.Errorf("客户端断开时不应发送错误响应: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+	}
+}
+
+// 测试处理函数按时返回但没有写入状态码时，设置的响应头依然会被发送
+func TestRouteTimeoutHeaders(t *testing.T) {
+	router := NewRouter(DefaultConfig())
+	router.Handle(http.MethodGet, "/empty", Timeout(func(ctx *Context) error {
+		ctx.Writer.Header().Set("X-Handled", "empty")
+		return nil
+	}, time.Second))
+	router.Handle(http.MethodGet, "/error", Timeout(func(ctx *Context) error {
+		ctx.Writer.Header().Set("X-Handled", "error")
+		return ErrBadRequest
+	}, time.Second))
+
+	for path, code := range map[string]int{"/empty": http.StatusOK, "/error": http.StatusBadRequest} {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		if resp.Code != code {
+			t.Errorf("%s: 状态码错误: 得到 %v, 期待 %v", path, resp.Code, code)
+		}
+		if handled := resp.Header().Get("X-Handled"); handled != path[1:] {
+			t.Errorf("%s: 响应头错误: 得到 %q, 期待 %q", path, handled, path[1:])
+		}
+	}
+}
+
+// 测试超时后处理函数发生的 panic 会被记录而不是被静默忽略
+func TestRouteTimeoutLatePanic(t *testing.T) {
+	var buf bytes.Buffer
+	logged := make(chan struct{})
+	log.SetOutput(writerFunc(func(p []byte) (int, error) {
+		buf.Write(p)
+		close(logged)
+		return len(p), nil
+	}))
+	defer log.SetOutput(os.Stderr)
+
+	router := NewRouter(DefaultConfig())
+	router.Handle(http.MethodGet, "/panic", Timeout(func(ctx *Context) error {
+		<-ctx.Context().Done()
+		panic("late boom")
+	}, time.Millisecond))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.Code, http.StatusServiceUnavailable)
+	}
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("超时后的 panic 没有被记录")
+	}
+	if !strings.Contains(buf.String(), "late boom") {
+		t.Errorf("日志内容错误: 得到 %q", buf.String())
+	}
+}
+
+// writerFunc 将函数适配为 io.Writer
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// 测试自定义错误处理函数与 *Error 状态码
+func TestErrorHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	router := NewRouter(cfg)
+	router.Handle(http.MethodGet, "/teapot", func(ctx *Context) error {
+		return NewError(http.StatusTeapot, "我是茶壶")
+	})
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+	if resp.Code != http.StatusTeapot || resp.Body.String() != "我是茶壶\n" {
+		t.Errorf("响应错误: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+	}
+
+	cfg.ErrorHandler = func(ctx *Context, err error) {
+		_ = ctx.SendError(http.StatusBadGateway, "custom: "+err.Error())
+	}
+	router = NewRouter(cfg)
+	router.Handle(http.MethodGet, "/fail", func(ctx *Context) error {
+		return errors.New("boom")
+	})
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if resp.Code != http.StatusBadGateway || resp.Body.String() != "custom: boom" {
+		t.Errorf("响应错误: 状态码 %v, 内容 %q", resp.Code, resp.Body.String())
+	}
+}
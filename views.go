@@ -0,0 +1,82 @@
+package kanggo
+
+import "net/http"
+
+// Render 使用模板引擎渲染名为 name 的模板并以状态码 code 响应
+// data 为 nil 或 map[string]interface{} 时，会先填入通过 ctx.Set 保存的请求局部变量，data 中的同名键优先；
+// 传入 layout 时使用该布局渲染（空字符串表示不使用布局），否则使用模板引擎的默认布局
+func (c *Context) Render(code int, name string, data interface{}, layout ...string) error {
+	if c.TemplateEngine == nil {
+		return ErrNoTemplateEngine
+	}
+
+	w := &statusWriter{ResponseWriter: c.Writer, code: code}
+	data = c.viewData(data)
+	if len(layout) == 0 {
+		return c.TemplateEngine.Render(w, name, data)
+	}
+	renderer, ok := c.TemplateEngine.(LayoutRenderer)
+	if !ok {
+		return ErrLayoutNotSupported
+	}
+	return renderer.RenderLayout(w, name, layout[0], data)
+}
+
+// viewData 将请求局部变量合并到模板数据中，其他类型的数据原样返回
+func (c *Context) viewData(data interface{}) interface{} {
+	if c.locals == nil || len(c.locals.values) == 0 {
+		return data
+	}
+	var values map[string]interface{}
+	switch v := data.(type) {
+	case nil:
+	case map[string]interface{}:
+		values = v
+	default:
+		return data
+	}
+
+	merged := make(map[string]interface{}, len(c.locals.values)+len(values))
+	for key, value := range c.locals.values {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return merged
+}
+
+// statusWriter 在第一次写入响应体时才发送状态码，让模板引擎仍可以设置 Content-Type 等响应头
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+// WriteHeader 发送状态码，模板引擎主动设置的状态码（例如模板未找到时的 500）优先
+func (w *statusWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 写入响应体，尚未发送状态码时先发送 Render 指定的状态码
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(w.code)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap 返回被包装的 ResponseWriter，供 http.ResponseController 使用
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LoadViews 加载 Config.Views 中的模板，Run 启动服务器前会自动调用
+// 不通过 Run 启动（例如在测试中直接使用 Router）时需要手动调用
+func (k *KangGo) LoadViews() error {
+	if k.Config.Views == nil {
+		return nil
+	}
+	return k.Config.Views.Load()
+}
@@ -0,0 +1,74 @@
+package kanggo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// 测试 Config.Views 自动注入、状态码、布局选择与局部变量合并
+func TestContextRender(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"layouts/main.html":  `<main>{{yield}}</main>`,
+		"layouts/admin.html": `<admin>{{yield}}</admin>`,
+		"index.html":         `{{.user}}:{{.title}}|<a href="{{url "home"}}">home</a>`,
+	})
+	app := New(Config{Views: NewHTMLTemplateEngine(dir, "*.html").Layout("layouts/main.html")})
+	if err := app.LoadViews(); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	app.GET("/home", func(ctx *Context) error {
+		ctx.Set("user", "kanggo")
+		ctx.Set("title", "被覆盖")
+		return ctx.Render(http.StatusCreated, "index.html", map[string]interface{}{"title": "首页"})
+	}).Name("home")
+	app.GET("/admin", func(ctx *Context) error {
+		ctx.Set("user", "admin")
+		return ctx.Render(http.StatusOK, "index.html", nil, "layouts/admin.html")
+	})
+	app.GET("/bare", func(ctx *Context) error {
+		return ctx.Render(http.StatusOK, "index.html", nil, "")
+	})
+
+	cases := []struct {
+		path     string
+		code     int
+		expected string
+	}{
+		{"/home", http.StatusCreated, `<main>kanggo:首页|<a href="/home">home</a></main>`},
+		{"/admin", http.StatusOK, `<admin>admin:|<a href="/home">home</a></admin>`},
+		{"/bare", http.StatusOK, `:|<a href="/home">home</a>`},
+	}
+	for _, tc := range cases {
+		resp := httptest.NewRecorder()
+		app.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if resp.Code != tc.code {
+			t.Errorf("%s: 状态码错误: 得到 %v, 期待 %v", tc.path, resp.Code, tc.code)
+		}
+		if resp.Body.String() != tc.expected {
+			t.Errorf("%s: 渲染结果不正确: 得到 %s，期望 %s", tc.path, resp.Body.String(), tc.expected)
+		}
+		if ct := resp.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: Content-Type 错误: 得到 %v", tc.path, ct)
+		}
+	}
+}
+
+// 测试未配置模板引擎时返回错误
+func TestContextRenderWithoutViews(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), Config{})
+	if err := ctx.Render(http.StatusOK, "index.html", nil); !errors.Is(err, ErrNoTemplateEngine) {
+		t.Errorf("期待 ErrNoTemplateEngine, 得到 %v", err)
+	}
+}
+
+// 测试 Run 在模板加载失败时返回错误
+func TestRunLoadViewsError(t *testing.T) {
+	app := New(Config{Views: NewHTMLTemplateEngine(filepath.Join(t.TempDir(), "missing"), "*.html")})
+	if err := app.Run("127.0.0.1:0"); err == nil {
+		t.Error("模板加载失败时 Run 应返回错误")
+	}
+}
@@ -0,0 +1,30 @@
+package kanggo
+
+import (
+	"github.com/7836246/kanggo/constants"
+	"github.com/7836246/kanggo/websocket"
+)
+
+// WebSocket 注册一个 WebSocket 路由，握手成功后调用 handler，handler 返回后连接自动关闭
+func (k *KangGo) WebSocket(pattern string, handler func(*websocket.Conn), config ...websocket.Config) *Route {
+	return k.Router.Handle(constants.MethodGet, pattern, webSocketHandler(handler, config...))
+}
+
+// WebSocket 方法为路由组注册一个 WebSocket 路由
+func (g *Group) WebSocket(pattern string, handler func(*websocket.Conn), config ...websocket.Config) *Route {
+	return g.Router.Handle(constants.MethodGet, g.Prefix+pattern, webSocketHandler(handler, config...))
+}
+
+// webSocketHandler 将 WebSocket 处理函数包装为普通的路由处理函数
+func webSocketHandler(handler func(*websocket.Conn), config ...websocket.Config) HandlerFunc {
+	return func(ctx *Context) error {
+		conn, err := websocket.Upgrade(ctx.Writer, ctx.Request, config...)
+		if err != nil {
+			return nil // 握手失败时 Upgrade 已经写入了错误响应
+		}
+		defer conn.Close()
+
+		handler(conn)
+		return nil
+	}
+}
@@ -0,0 +1,155 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dial 作为客户端连接 WebSocket 服务器，rawURL 的协议可以是 ws、wss、http 或 https
+// header 中的字段（如 Origin、Cookie）会随握手请求一起发送
+// 握手失败时，如果已经收到服务器响应，会一并返回以便检查状态码
+func Dial(rawURL string, header http.Header, config ...Config) (*Conn, *http.Response, error) {
+	cfg := configDefault(config...)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	useTLS := false
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme, useTLS = "https", true
+	default:
+		return nil, nil, fmt.Errorf("%w: 不支持的协议 %q", ErrBadHandshake, u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		if useTLS {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	ctx := context.Background()
+	if cfg.HandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.HandshakeTimeout)
+		defer cancel()
+	}
+
+	var netConn net.Conn
+	if useTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		netConn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		netConn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	conn, resp, err := clientHandshake(netConn, u, header, cfg)
+	if err != nil {
+		netConn.Close()
+		return nil, resp, err
+	}
+	_ = netConn.SetDeadline(time.Time{})
+	return conn, resp, nil
+}
+
+// clientHandshake 在已建立的网络连接上发送握手请求并校验响应
+func clientHandshake(netConn net.Conn, u *url.URL, header http.Header, cfg Config) (*Conn, *http.Response, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(cfg.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(cfg.Subprotocols, ", "))
+	}
+	if cfg.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateResponse)
+	}
+
+	if err := req.Write(netConn); err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReaderSize(netConn, cfg.ReadBufferSize)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") {
+		return nil, resp, fmt.Errorf("%w: 服务器返回 %s", ErrBadHandshake, resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != computeAcceptKey(key) {
+		return nil, resp, fmt.Errorf("%w: Sec-WebSocket-Accept 不匹配", ErrBadHandshake)
+	}
+
+	subprotocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" && !containsString(cfg.Subprotocols, subprotocol) {
+		return nil, resp, fmt.Errorf("%w: 服务器选择了未请求的子协议 %q", ErrBadHandshake, subprotocol)
+	}
+
+	compress := false
+	for _, ext := range parseExtensions(resp.Header.Values("Sec-WebSocket-Extensions")) {
+		if ext.name != permessageDeflate || !cfg.EnableCompression {
+			return nil, resp, fmt.Errorf("%w: 服务器启用了未请求的扩展 %q", ErrBadHandshake, ext.name)
+		}
+		// 客户端的解压依赖服务端不保留压缩上下文
+		if _, ok := ext.params["server_no_context_takeover"]; !ok {
+			return nil, resp, fmt.Errorf("%w: 服务器未接受 server_no_context_takeover", ErrBadHandshake)
+		}
+		compress = true
+	}
+
+	return newConn(netConn, br, false, cfg, compress, subprotocol), resp, nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
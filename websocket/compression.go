@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+)
+
+// permessageDeflate 是 RFC 7692 定义的压缩扩展名称
+const permessageDeflate = "permessage-deflate"
+
+// deflateTail 是同步刷新时 DEFLATE 输出末尾固定的 4 个字节，发送前需要去掉、解压前需要补回
+const deflateTail = "\x00\x00\xff\xff"
+
+// deflateFinalBlock 在补回的尾部之后追加一个空的最终块，使解压器能够正常结束
+const deflateFinalBlock = "\x01\x00\x00\xff\xff"
+
+// flateWriterPools 按压缩级别缓存 flate.Writer
+var flateWriterPools sync.Map
+
+// compress 压缩一条消息；由于协商了 no_context_takeover，每条消息都使用全新的压缩上下文
+func compress(data []byte, level int) ([]byte, error) {
+	poolValue, _ := flateWriterPools.LoadOrStore(level, &sync.Pool{})
+	pool := poolValue.(*sync.Pool)
+
+	var buf bytes.Buffer
+	fw, ok := pool.Get().(*flate.Writer)
+	if ok {
+		fw.Reset(&buf)
+	} else {
+		var err error
+		if fw, err = flate.NewWriter(&buf, level); err != nil {
+			return nil, err
+		}
+	}
+	defer pool.Put(fw)
+
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte(deflateTail)), nil
+}
+
+// decompress 解压一条消息，解压后超过 limit 字节时返回 ErrReadLimit
+func decompress(data []byte, limit int64) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(
+		bytes.NewReader(data),
+		strings.NewReader(deflateTail+deflateFinalBlock),
+	))
+	defer fr.Close()
+
+	var reader io.Reader = fr
+	if limit > 0 {
+		reader = io.LimitReader(fr, limit+1)
+	}
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(out)) > limit {
+		return nil, ErrReadLimit
+	}
+	return out, nil
+}
+
+// extension 表示 Sec-WebSocket-Extensions 中的一项扩展及其参数
+type extension struct {
+	name   string
+	params map[string]string
+}
+
+// parseExtensions 解析 Sec-WebSocket-Extensions 请求头（可能出现多次）
+func parseExtensions(values []string) []extension {
+	var extensions []extension
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(item, ";")
+			ext := extension{
+				name:   strings.ToLower(strings.TrimSpace(parts[0])),
+				params: make(map[string]string),
+			}
+			if ext.name == "" {
+				continue
+			}
+			for _, param := range parts[1:] {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				ext.params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
+// acceptDeflateOffer 判断客户端的 permessage-deflate 请求能否被接受
+// 本实现总是使用 32 KB 窗口且不保留压缩上下文，因此无法满足更小的 server_max_window_bits
+func acceptDeflateOffer(ext extension) bool {
+	if ext.name != permessageDeflate {
+		return false
+	}
+	for key, val := range ext.params {
+		switch key {
+		case "server_no_context_takeover", "client_no_context_takeover":
+		case "client_max_window_bits":
+			// 服务端可以不回应该参数，客户端将使用默认的 15
+		case "server_max_window_bits":
+			if val != "" && val != "15" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// deflateResponse 是服务端接受压缩时返回的扩展参数
+const deflateResponse = permessageDeflate + "; server_no_context_takeover; client_no_context_takeover"
@@ -0,0 +1,569 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 帧头部中的标志位
+const (
+	finalBit = 0x80
+	rsv1Bit  = 0x40
+	rsv2Bit  = 0x20
+	rsv3Bit  = 0x10
+	maskBit  = 0x80
+
+	continuationFrame = 0
+	maxControlPayload = 125
+
+	// maxReadLimit 是单条消息的硬性上限，ReadLimit 为 0 或更大时也不会超过它，
+	// 防止对方在帧头中声明巨大的长度导致一次性分配大量内存
+	maxReadLimit = 64 * 1024 * 1024
+)
+
+// frameHeader 是解析后的帧头部
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode int
+	masked bool
+	mask   [4]byte
+	length int64
+}
+
+// Conn 表示一条 WebSocket 连接
+// 同一时刻只能有一个 goroutine 读取消息；写入方法可以在多个 goroutine 中并发调用
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool
+	subprotocol string
+	request     *http.Request
+
+	compress         bool // 是否已协商 permessage-deflate
+	compressionLevel int
+	readLimit        int64
+	writeLimit       int64
+
+	readErr      error // 读取出错后的粘滞错误
+	pingHandler  func(appData string) error
+	pongHandler  func(appData string) error
+	closeHandler func(code int, text string) error
+
+	writeMu   sync.Mutex
+	bw        *bufio.Writer
+	closeSent bool
+}
+
+// newConn 使用协商后的参数创建连接
+func newConn(netConn net.Conn, br *bufio.Reader, isServer bool, cfg Config, compress bool, subprotocol string) *Conn {
+	if br == nil {
+		br = bufio.NewReaderSize(netConn, cfg.ReadBufferSize)
+	}
+	c := &Conn{
+		conn:             netConn,
+		br:               br,
+		bw:               bufio.NewWriterSize(netConn, cfg.WriteBufferSize),
+		isServer:         isServer,
+		subprotocol:      subprotocol,
+		compress:         compress,
+		compressionLevel: cfg.CompressionLevel,
+		readLimit:        cfg.ReadLimit,
+		writeLimit:       cfg.WriteLimit,
+	}
+	c.pingHandler = c.defaultPingHandler
+	c.pongHandler = c.defaultPongHandler
+	c.closeHandler = c.defaultCloseHandler
+	return c
+}
+
+// Subprotocol 返回握手时协商的子协议
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Compressed 返回连接是否启用了 permessage-deflate 压缩
+func (c *Conn) Compressed() bool {
+	return c.compress
+}
+
+// Request 返回服务端握手时的 HTTP 请求，客户端连接返回 nil
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// LocalAddr 返回本地网络地址
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr 返回远端网络地址
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// NetConn 返回底层的网络连接
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// SetReadDeadline 设置读取超时时间，超时后连接不可再读取
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline 设置写入超时时间
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit 设置单条消息（解压后）的最大字节数，0 或超过 64 MB 时使用 64 MB 的上限
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetWriteLimit 设置单条发送消息的最大字节数，0 表示不限制
+func (c *Conn) SetWriteLimit(limit int64) {
+	c.writeLimit = limit
+}
+
+// SetPingHandler 设置收到 Ping 时的处理函数，传入 nil 恢复默认行为（回复 Pong）
+// 处理函数在 ReadMessage 内部调用
+func (c *Conn) SetPingHandler(h func(appData string) error) {
+	if h == nil {
+		h = c.defaultPingHandler
+	}
+	c.pingHandler = h
+}
+
+// SetPongHandler 设置收到 Pong 时的处理函数，常用于刷新读取超时时间
+func (c *Conn) SetPongHandler(h func(appData string) error) {
+	if h == nil {
+		h = c.defaultPongHandler
+	}
+	c.pongHandler = h
+}
+
+// SetCloseHandler 设置收到关闭帧时的处理函数，传入 nil 恢复默认行为（回显关闭码）
+func (c *Conn) SetCloseHandler(h func(code int, text string) error) {
+	if h == nil {
+		h = c.defaultCloseHandler
+	}
+	c.closeHandler = h
+}
+
+// defaultPingHandler 回复内容相同的 Pong
+func (c *Conn) defaultPingHandler(appData string) error {
+	err := c.WriteControl(PongMessage, []byte(appData), time.Now().Add(time.Second))
+	if errors.Is(err, ErrCloseSent) {
+		return nil
+	}
+	return err
+}
+
+// defaultPongHandler 忽略收到的 Pong
+func (c *Conn) defaultPongHandler(string) error {
+	return nil
+}
+
+// defaultCloseHandler 按协议回复关闭帧，回显对方的关闭码
+func (c *Conn) defaultCloseHandler(code int, _ string) error {
+	return c.writeClose(FormatCloseMessage(code, ""))
+}
+
+// ReadMessage 读取下一条完整的数据消息，期间自动处理分片与控制帧
+// 收到关闭帧时返回 *CloseError；出现任何错误后连接不可再读取
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	var (
+		payload    []byte
+		compressed bool
+	)
+	for {
+		h, err := c.readFrameHeader()
+		if err != nil {
+			return 0, nil, c.failRead(err)
+		}
+
+		// 控制帧可以穿插在分片消息之间
+		if h.opcode >= CloseMessage {
+			if err := c.handleControl(h); err != nil {
+				return 0, nil, c.failRead(err)
+			}
+			continue
+		}
+
+		if h.opcode == continuationFrame {
+			if messageType == 0 {
+				return 0, nil, c.failProtocol(CloseProtocolError, "收到意外的后续帧")
+			}
+		} else {
+			if messageType != 0 {
+				return 0, nil, c.failProtocol(CloseProtocolError, "分片消息尚未结束")
+			}
+			messageType, compressed = h.opcode, h.rsv1
+		}
+
+		if int64(len(payload))+h.length > c.maxMessageSize() {
+			return 0, nil, c.failLimit()
+		}
+		data, err := c.readPayload(h)
+		if err != nil {
+			return 0, nil, c.failRead(err)
+		}
+		payload = append(payload, data...)
+		if h.fin {
+			break
+		}
+	}
+
+	if compressed {
+		if payload, err = decompress(payload, c.maxMessageSize()); err != nil {
+			if errors.Is(err, ErrReadLimit) {
+				return 0, nil, c.failLimit()
+			}
+			return 0, nil, c.failProtocol(CloseInvalidFramePayloadData, "无法解压消息")
+		}
+	}
+	if messageType == TextMessage && !utf8.Valid(payload) {
+		return 0, nil, c.failProtocol(CloseInvalidFramePayloadData, "文本消息不是合法的 UTF-8")
+	}
+	return messageType, payload, nil
+}
+
+// ReadJSON 读取下一条消息并解码为 JSON
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage 发送一条完整的文本或二进制消息；启用压缩时自动压缩
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: 不支持的消息类型 %d", messageType)
+	}
+	if c.writeLimit > 0 && int64(len(data)) > c.writeLimit {
+		return ErrWriteLimit
+	}
+
+	rsv1 := false
+	if c.compress {
+		compressed, err := compress(data, c.compressionLevel)
+		if err != nil {
+			return err
+		}
+		data, rsv1 = compressed, true
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	return c.writeFrame(messageType, data, rsv1)
+}
+
+// WriteJSON 将对象编码为 JSON 并作为文本消息发送
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// WriteControl 发送一个控制帧（Close、Ping 或 Pong），deadline 为零值时不设置写入超时
+func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType != CloseMessage && messageType != PingMessage && messageType != PongMessage {
+		return fmt.Errorf("websocket: 不是控制帧类型 %d", messageType)
+	}
+	if len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: 控制帧内容不能超过 %d 字节", maxControlPayload)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if !deadline.IsZero() {
+		_ = c.conn.SetWriteDeadline(deadline)
+		defer c.conn.SetWriteDeadline(time.Time{})
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	return c.writeFrame(messageType, data, false)
+}
+
+// Ping 发送一个 Ping 控制帧
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteControl(PingMessage, data, time.Time{})
+}
+
+// WriteClose 发送带有关闭码和原因的关闭帧，之后不能再发送数据
+func (c *Conn) WriteClose(code int, reason string) error {
+	return c.WriteControl(CloseMessage, FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+// Close 发送正常关闭帧（如尚未发送）并关闭底层网络连接
+func (c *Conn) Close() error {
+	_ = c.WriteClose(CloseNormalClosure, "")
+	return c.conn.Close()
+}
+
+// FormatCloseMessage 按协议格式构造关闭帧的内容
+func FormatCloseMessage(code int, text string) []byte {
+	if code == CloseNoStatusReceived {
+		return nil
+	}
+	buf := make([]byte, 2+len(text))
+	binary.BigEndian.PutUint16(buf, uint16(code))
+	copy(buf[2:], text)
+	return buf
+}
+
+// writeClose 在 writeMu 之外发送关闭帧，已发送过时忽略
+func (c *Conn) writeClose(data []byte) error {
+	err := c.WriteControl(CloseMessage, data, time.Now().Add(time.Second))
+	if errors.Is(err, ErrCloseSent) {
+		return nil
+	}
+	return err
+}
+
+// maxMessageSize 返回实际生效的读取限制
+func (c *Conn) maxMessageSize() int64 {
+	if c.readLimit <= 0 || c.readLimit > maxReadLimit {
+		return maxReadLimit
+	}
+	return c.readLimit
+}
+
+// readFrameHeader 读取并校验一个帧头部
+func (c *Conn) readFrameHeader() (frameHeader, error) {
+	var h frameHeader
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return h, err
+	}
+
+	h.fin = head[0]&finalBit != 0
+	h.rsv1 = head[0]&rsv1Bit != 0
+	h.opcode = int(head[0] & 0x0f)
+	h.masked = head[1]&maskBit != 0
+	h.length = int64(head[1] & 0x7f)
+
+	switch {
+	case head[0]&(rsv2Bit|rsv3Bit) != 0:
+		return h, c.protocolError(CloseProtocolError, "使用了未协商的保留位")
+	case h.rsv1 && (!c.compress || h.opcode == continuationFrame || h.opcode >= CloseMessage):
+		return h, c.protocolError(CloseProtocolError, "使用了未协商的保留位")
+	case h.opcode != continuationFrame && h.opcode != TextMessage && h.opcode != BinaryMessage &&
+		h.opcode != CloseMessage && h.opcode != PingMessage && h.opcode != PongMessage:
+		return h, c.protocolError(CloseProtocolError, fmt.Sprintf("未知的操作码 %d", h.opcode))
+	case c.isServer && !h.masked:
+		return h, c.protocolError(CloseProtocolError, "客户端帧必须使用掩码")
+	case !c.isServer && h.masked:
+		return h, c.protocolError(CloseProtocolError, "服务端帧不能使用掩码")
+	}
+
+	switch h.length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return h, err
+		}
+		length := binary.BigEndian.Uint64(ext[:])
+		if length > 1<<63-1 {
+			return h, c.protocolError(CloseProtocolError, "帧长度无效")
+		}
+		h.length = int64(length)
+	}
+
+	if h.opcode >= CloseMessage && (!h.fin || h.length > maxControlPayload) {
+		return h, c.protocolError(CloseProtocolError, "控制帧不能分片且内容不能超过 125 字节")
+	}
+
+	if h.masked {
+		if _, err := io.ReadFull(c.br, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+// readPayload 读取帧内容并去除掩码
+func (c *Conn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return nil, err
+	}
+	if h.masked {
+		maskBytes(h.mask, payload)
+	}
+	return payload, nil
+}
+
+// handleControl 处理 Close、Ping、Pong 控制帧
+func (c *Conn) handleControl(h frameHeader) error {
+	payload, err := c.readPayload(h)
+	if err != nil {
+		return err
+	}
+
+	switch h.opcode {
+	case PingMessage:
+		return c.pingHandler(string(payload))
+	case PongMessage:
+		return c.pongHandler(string(payload))
+	}
+
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.protocolError(CloseProtocolError, "关闭帧内容无效")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) {
+			return c.protocolError(CloseProtocolError, fmt.Sprintf("无效的关闭码 %d", closeErr.Code))
+		}
+		if !utf8.ValidString(closeErr.Text) {
+			return c.protocolError(CloseInvalidFramePayloadData, "关闭原因不是合法的 UTF-8")
+		}
+	}
+
+	if err := c.closeHandler(closeErr.Code, closeErr.Text); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// writeFrame 写入一个完整的帧，调用方需持有 writeMu
+func (c *Conn) writeFrame(opcode int, payload []byte, rsv1 bool) error {
+	var header [14]byte
+	header[0] = finalBit | byte(opcode)
+	if rsv1 {
+		header[0] |= rsv1Bit
+	}
+
+	n := 2
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+		n += 2
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+		n += 8
+	}
+
+	// 客户端发送的帧必须使用随机掩码
+	if !c.isServer {
+		header[1] |= maskBit
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		copy(header[n:], key[:])
+		n += 4
+		masked := make([]byte, length)
+		copy(masked, payload)
+		maskBytes(key, masked)
+		payload = masked
+	}
+
+	if _, err := c.bw.Write(header[:n]); err != nil {
+		return err
+	}
+	if _, err := c.bw.Write(payload); err != nil {
+		return err
+	}
+	return c.bw.Flush()
+}
+
+// protocolError 构造携带关闭码的协议错误
+func (c *Conn) protocolError(code int, text string) error {
+	return &protocolViolation{code: code, text: text}
+}
+
+// failProtocol 以指定关闭码关闭连接并记录读取错误
+func (c *Conn) failProtocol(code int, text string) error {
+	return c.failRead(c.protocolError(code, text))
+}
+
+// failLimit 以 1009 关闭连接并返回 ErrReadLimit
+func (c *Conn) failLimit() error {
+	_ = c.writeClose(FormatCloseMessage(CloseMessageTooBig, ""))
+	c.readErr = ErrReadLimit
+	return c.readErr
+}
+
+// failRead 记录读取错误；对于协议错误会先发送对应的关闭帧
+func (c *Conn) failRead(err error) error {
+	var violation *protocolViolation
+	if errors.As(err, &violation) {
+		_ = c.writeClose(FormatCloseMessage(violation.code, ""))
+		err = fmt.Errorf("%w: %s", ErrProtocol, violation.text)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = &CloseError{Code: CloseAbnormalClosure, Text: io.ErrUnexpectedEOF.Error()}
+	}
+	c.readErr = err
+	return err
+}
+
+// protocolViolation 表示检测到的协议错误及应使用的关闭码
+type protocolViolation struct {
+	code int
+	text string
+}
+
+func (e *protocolViolation) Error() string {
+	return e.text
+}
+
+// validCloseCode 判断关闭码是否允许出现在关闭帧中
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// maskBytes 使用掩码对数据进行异或运算，加掩码与去掩码是同一操作
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
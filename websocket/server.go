@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// acceptGUID 是 RFC 6455 规定的用于计算 Sec-WebSocket-Accept 的固定字符串
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// IsWebSocketUpgrade 判断请求是否为 WebSocket 升级请求
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade 完成服务端握手并将 HTTP 连接升级为 WebSocket 连接
+// 握手失败时会直接写入相应的 HTTP 错误响应，并返回包装了 ErrBadHandshake 的错误
+func Upgrade(w http.ResponseWriter, r *http.Request, config ...Config) (*Conn, error) {
+	cfg := configDefault(config...)
+
+	if r.Method != http.MethodGet {
+		return nil, handshakeError(w, http.StatusMethodNotAllowed, "请求方法必须为 GET")
+	}
+	if !IsWebSocketUpgrade(r) {
+		return nil, handshakeError(w, http.StatusBadRequest, "缺少 Upgrade: websocket 请求头")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, handshakeError(w, http.StatusUpgradeRequired, "仅支持版本 13")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, handshakeError(w, http.StatusBadRequest, "Sec-WebSocket-Key 无效")
+	}
+	if !checkOrigin(r, cfg) {
+		return nil, handshakeError(w, http.StatusForbidden, "来源不被允许")
+	}
+
+	subprotocol := selectSubprotocol(r, cfg.Subprotocols)
+	compress := false
+	if cfg.EnableCompression {
+		for _, ext := range parseExtensions(r.Header.Values("Sec-WebSocket-Extensions")) {
+			if acceptDeflateOffer(ext) {
+				compress = true
+				break
+			}
+		}
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, handshakeError(w, http.StatusInternalServerError, "无法接管连接: "+err.Error())
+	}
+	// 服务器可能为连接设置了读写超时，升级后由调用方自行管理
+	_ = netConn.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + computeAcceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: " + deflateResponse + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	conn := newConn(netConn, brw.Reader, true, cfg, compress, subprotocol)
+	conn.request = r
+	return conn, nil
+}
+
+// handshakeError 写入握手失败的 HTTP 响应并返回错误
+func handshakeError(w http.ResponseWriter, status int, reason string) error {
+	http.Error(w, http.StatusText(status), status)
+	return fmt.Errorf("%w: %s", ErrBadHandshake, reason)
+}
+
+// checkOrigin 按配置检查请求来源，防止跨站 WebSocket 劫持
+func checkOrigin(r *http.Request, cfg Config) bool {
+	if cfg.CheckOrigin != nil {
+		return cfg.CheckOrigin(r)
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // 非浏览器客户端通常不发送 Origin
+	}
+	if len(cfg.Origins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, allowed := range cfg.Origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectSubprotocol 按服务端的优先顺序选择客户端提供的子协议
+func selectSubprotocol(r *http.Request, supported []string) string {
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, protocol := range supported {
+		for _, offer := range offered {
+			if offer == protocol {
+				return protocol
+			}
+		}
+	}
+	return ""
+}
+
+// computeAcceptKey 根据 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func computeAcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// headerTokens 返回逗号分隔的请求头中的所有值
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken 判断逗号分隔的请求头中是否包含指定值（不区分大小写）
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
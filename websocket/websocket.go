@@ -0,0 +1,128 @@
+// Package websocket 提供不依赖第三方库的 RFC 6455 WebSocket 实现，
+// 支持文本/二进制消息、Ping/Pong、关闭码、permessage-deflate 压缩、来源检查以及读写限制。
+package websocket
+
+import (
+	"compress/flate"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 消息类型，与 RFC 6455 中的操作码一致
+const (
+	TextMessage   = 1  // 文本消息，内容必须是合法的 UTF-8
+	BinaryMessage = 2  // 二进制消息
+	CloseMessage  = 8  // 关闭控制帧
+	PingMessage   = 9  // Ping 控制帧
+	PongMessage   = 10 // Pong 控制帧
+)
+
+// 关闭码，参见 RFC 6455 第 7.4.1 节
+const (
+	CloseNormalClosure           = 1000 // 正常关闭
+	CloseGoingAway               = 1001 // 端点离开，例如服务器关闭或页面跳转
+	CloseProtocolError           = 1002 // 协议错误
+	CloseUnsupportedData         = 1003 // 收到无法处理的数据类型
+	CloseNoStatusReceived        = 1005 // 关闭帧中没有状态码（不会出现在线路上）
+	CloseAbnormalClosure         = 1006 // 连接异常断开（不会出现在线路上）
+	CloseInvalidFramePayloadData = 1007 // 消息内容与类型不符，例如文本消息不是合法的 UTF-8
+	ClosePolicyViolation         = 1008 // 违反策略
+	CloseMessageTooBig           = 1009 // 消息过大
+	CloseMandatoryExtension      = 1010 // 客户端要求的扩展未被服务器协商
+	CloseInternalServerErr       = 1011 // 服务器内部错误
+	CloseTLSHandshake            = 1015 // TLS 握手失败（不会出现在线路上）
+)
+
+var (
+	// ErrBadHandshake 表示 WebSocket 握手失败
+	ErrBadHandshake = errors.New("websocket: 握手失败")
+	// ErrReadLimit 表示收到的消息超出了 Config.ReadLimit
+	ErrReadLimit = errors.New("websocket: 消息超出读取限制")
+	// ErrWriteLimit 表示要发送的消息超出了 Config.WriteLimit
+	ErrWriteLimit = errors.New("websocket: 消息超出写入限制")
+	// ErrCloseSent 表示已经发送过关闭帧，不能再发送数据
+	ErrCloseSent = errors.New("websocket: 已发送关闭帧")
+	// ErrProtocol 表示对端违反了 WebSocket 协议
+	ErrProtocol = errors.New("websocket: 协议错误")
+)
+
+// CloseError 表示收到了对端的关闭帧
+type CloseError struct {
+	Code int    // 关闭码
+	Text string // 关闭原因
+}
+
+// Error 实现 error 接口
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return "websocket: 连接已关闭 (" + strconv.Itoa(e.Code) + ")"
+	}
+	return fmt.Sprintf("websocket: 连接已关闭 (%d): %s", e.Code, e.Text)
+}
+
+// IsCloseError 判断 err 是否为关闭码属于 codes 之一的 CloseError
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Config 是 WebSocket 连接的配置结构体，服务端的 Upgrade 与客户端的 Dial 共用
+type Config struct {
+	Origins           []string                   // 服务端：允许的 Origin 列表，"*" 表示允许所有来源；为空时只允许同源请求
+	CheckOrigin       func(r *http.Request) bool // 服务端：自定义来源检查函数，设置后忽略 Origins
+	Subprotocols      []string                   // 支持的子协议，服务端按此顺序选择客户端提供的第一个匹配项
+	EnableCompression bool                       // 是否协商 permessage-deflate 压缩，默认值 false
+	CompressionLevel  int                        // 压缩级别，默认值 flate.BestSpeed
+	ReadLimit         int64                      // 单条消息（解压后）的最大字节数，超出时以 1009 关闭连接，默认值 4 MB，最大 64 MB
+	WriteLimit        int64                      // 单条发送消息的最大字节数，0 表示不限制，默认值 0
+	ReadBufferSize    int                        // 读缓冲区大小，默认值 4096
+	WriteBufferSize   int                        // 写缓冲区大小，默认值 4096
+	HandshakeTimeout  time.Duration              // 客户端：握手超时时间，0 表示不限制，默认值 0
+}
+
+// ConfigDefault 默认配置
+var ConfigDefault = Config{
+	Origins:           nil,
+	CheckOrigin:       nil,
+	Subprotocols:      nil,
+	EnableCompression: false,
+	CompressionLevel:  flate.BestSpeed,
+	ReadLimit:         4 * 1024 * 1024,
+	WriteLimit:        0,
+	ReadBufferSize:    4096,
+	WriteBufferSize:   4096,
+	HandshakeTimeout:  0,
+}
+
+// configDefault 为未设置的配置项填充默认值
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+
+		if cfg.CompressionLevel == 0 {
+			cfg.CompressionLevel = ConfigDefault.CompressionLevel
+		}
+		if cfg.ReadLimit <= 0 {
+			cfg.ReadLimit = ConfigDefault.ReadLimit
+		}
+		if cfg.ReadBufferSize <= 0 {
+			cfg.ReadBufferSize = ConfigDefault.ReadBufferSize
+		}
+		if cfg.WriteBufferSize <= 0 {
+			cfg.WriteBufferSize = ConfigDefault.WriteBufferSize
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,335 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEchoServer 启动一个将收到的消息原样返回的 WebSocket 服务器
+func newEchoServer(t *testing.T, cfg Config) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, cfg)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// wsURL 将 httptest 服务器地址转换为 ws:// 地址
+func wsURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// 测试文本与二进制消息的收发
+func TestEcho(t *testing.T) {
+	for _, compression := range []bool{false, true} {
+		cfg := Config{EnableCompression: compression}
+		server := newEchoServer(t, cfg)
+
+		conn, resp, err := Dial(wsURL(server), nil, cfg)
+		if err != nil {
+			t.Fatalf("连接失败: %v", err)
+		}
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.StatusCode, http.StatusSwitchingProtocols)
+		}
+		if conn.Compressed() != compression {
+			t.Errorf("压缩协商错误: 得到 %v, 期待 %v", conn.Compressed(), compression)
+		}
+
+		messages := []struct {
+			messageType int
+			data        []byte
+		}{
+			{TextMessage, []byte("你好, KangGo")},
+			{BinaryMessage, []byte{0, 1, 2, 3, 255}},
+			{TextMessage, []byte(strings.Repeat("large", 20000))},
+			{TextMessage, []byte{}},
+		}
+		for _, msg := range messages {
+			if err := conn.WriteMessage(msg.messageType, msg.data); err != nil {
+				t.Fatalf("发送消息失败: %v", err)
+			}
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("读取消息失败: %v", err)
+			}
+			if messageType != msg.messageType || !bytes.Equal(data, msg.data) {
+				t.Errorf("回显消息错误: 压缩=%v, 类型 %d, 长度 %d", compression, messageType, len(data))
+			}
+		}
+		conn.Close()
+	}
+}
+
+// 测试 Ping/Pong 与关闭码
+func TestPingPongAndClose(t *testing.T) {
+	server := newEchoServer(t, Config{})
+	conn, _, err := Dial(wsURL(server), nil)
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(appData string) error {
+		pong <- appData
+		return nil
+	})
+	if err := conn.Ping([]byte("ping-data")); err != nil {
+		t.Fatalf("发送 Ping 失败: %v", err)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("after ping")); err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "after ping" {
+		t.Fatalf("读取消息失败: %v, %q", err, data)
+	}
+	select {
+	case data := <-pong:
+		if data != "ping-data" {
+			t.Errorf("Pong 内容错误: 得到 %q, 期待 %q", data, "ping-data")
+		}
+	default:
+		t.Error("未收到 Pong")
+	}
+
+	if err := conn.WriteClose(4000, "bye"); err != nil {
+		t.Fatalf("发送关闭帧失败: %v", err)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("late")); !errors.Is(err, ErrCloseSent) {
+		t.Errorf("关闭后发送应返回 ErrCloseSent, 得到 %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !IsCloseError(err, 4000) {
+		t.Errorf("期待回显关闭码 4000, 得到 %v", err)
+	}
+}
+
+// 测试来源检查
+func TestCheckOrigin(t *testing.T) {
+	server := newEchoServer(t, Config{Origins: []string{"https://kanggo.dev"}})
+
+	_, resp, err := Dial(wsURL(server), http.Header{"Origin": {"https://evil.example"}})
+	if !errors.Is(err, ErrBadHandshake) {
+		t.Fatalf("期待 ErrBadHandshake, 得到 %v", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("非法来源应返回 403")
+	}
+
+	conn, _, err := Dial(wsURL(server), http.Header{"Origin": {"https://kanggo.dev"}})
+	if err != nil {
+		t.Fatalf("允许的来源连接失败: %v", err)
+	}
+	conn.Close()
+
+	sameOrigin := newEchoServer(t, Config{})
+	_, resp, _ = Dial(wsURL(sameOrigin), http.Header{"Origin": {"https://evil.example"}})
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("默认只允许同源请求")
+	}
+}
+
+// 测试子协议协商与普通 HTTP 请求被拒绝
+func TestHandshake(t *testing.T) {
+	server := newEchoServer(t, Config{Subprotocols: []string{"v2.kanggo", "v1.kanggo"}})
+
+	conn, _, err := Dial(wsURL(server), nil, Config{Subprotocols: []string{"v1.kanggo", "v2.kanggo"}})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if conn.Subprotocol() != "v2.kanggo" {
+		t.Errorf("子协议错误: 得到 %q, 期待 %q", conn.Subprotocol(), "v2.kanggo")
+	}
+	conn.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+// 测试读写限制
+func TestReadWriteLimit(t *testing.T) {
+	server := newEchoServer(t, Config{ReadLimit: 16})
+	conn, _, err := Dial(wsURL(server), nil, Config{WriteLimit: 32})
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(TextMessage, make([]byte, 33)); !errors.Is(err, ErrWriteLimit) {
+		t.Errorf("期待 ErrWriteLimit, 得到 %v", err)
+	}
+	if err := conn.WriteMessage(BinaryMessage, make([]byte, 32)); err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+		t.Errorf("期待关闭码 1009, 得到 %v", err)
+	}
+}
+
+// clientFrame 构造一个带掩码的客户端帧
+func clientFrame(fin bool, opcode int, payload []byte) []byte {
+	var frame []byte
+	first := byte(opcode)
+	if fin {
+		first |= finalBit
+	}
+	frame = append(frame, first)
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, maskBit|byte(len(payload)))
+	default:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	key := [4]byte{1, 2, 3, 4}
+	frame = append(frame, key[:]...)
+	masked := append([]byte(nil), payload...)
+	maskBytes(key, masked)
+	return append(frame, masked...)
+}
+
+// newPipeConn 使用内存管道创建一条服务端连接，返回连接与客户端一侧
+func newPipeConn(t *testing.T) (*Conn, net.Conn) {
+	t.Helper()
+	serverSide, clientSide := net.Pipe()
+	t.Cleanup(func() {
+		serverSide.Close()
+		clientSide.Close()
+	})
+	// 丢弃服务端写回的帧
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			if _, err := clientSide.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	return newConn(serverSide, nil, true, configDefault(), false, ""), clientSide
+}
+
+// 测试分片消息与穿插其中的控制帧
+func TestFragmentedMessage(t *testing.T) {
+	conn, client := newPipeConn(t)
+	go func() {
+		client.Write(clientFrame(false, TextMessage, []byte("Hello, ")))
+		client.Write(clientFrame(true, PingMessage, []byte("p")))
+		client.Write(clientFrame(true, continuationFrame, []byte("KangGo")))
+	}()
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("读取消息失败: %v", err)
+	}
+	if messageType != TextMessage || string(data) != "Hello, KangGo" {
+		t.Errorf("分片消息错误: 类型 %d, 内容 %q", messageType, data)
+	}
+}
+
+// 测试协议错误的处理
+func TestProtocolErrors(t *testing.T) {
+	unmasked := []byte{finalBit | TextMessage, 2, 'h', 'i'}
+
+	cases := []struct {
+		name  string
+		frame []byte
+	}{
+		{"未加掩码", unmasked},
+		{"非法 UTF-8", clientFrame(true, TextMessage, []byte{0xff, 0xfe})},
+		{"意外的后续帧", clientFrame(true, continuationFrame, []byte("x"))},
+		{"分片的控制帧", clientFrame(false, PingMessage, nil)},
+		{"未知操作码", clientFrame(true, 3, nil)},
+		{"非法关闭码", clientFrame(true, CloseMessage, []byte{0x03, 0xed})},
+	}
+
+	for _, tc := range cases {
+		conn, client := newPipeConn(t)
+		go client.Write(tc.frame)
+
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := conn.ReadMessage()
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrProtocol) {
+				t.Errorf("%s: 期待 ErrProtocol, 得到 %v", tc.name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: 读取超时", tc.name)
+		}
+	}
+}
+
+// 测试读取限制为 0 时仍按硬性上限拒绝声明了巨大长度的帧，不会按帧头分配内存
+func TestReadLimitHardCap(t *testing.T) {
+	conn, client := newPipeConn(t)
+	conn.SetReadLimit(0)
+
+	frame := []byte{finalBit | BinaryMessage, maskBit | 127}
+	frame = binary.BigEndian.AppendUint64(frame, 1<<62)
+	frame = append(frame, 1, 2, 3, 4)
+	go client.Write(frame)
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrReadLimit) {
+			t.Errorf("期待 ErrReadLimit, 得到 %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("读取超时")
+	}
+}
+
+// 测试压缩与解压
+func TestCompression(t *testing.T) {
+	data := []byte(strings.Repeat("kanggo ", 100))
+	compressed, err := compress(data, ConfigDefault.CompressionLevel)
+	if err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+	if len(compressed) >= len(data) {
+		t.Errorf("压缩后体积未减小: %d >= %d", len(compressed), len(data))
+	}
+	out, err := decompress(compressed, 0)
+	if err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("解压结果错误: %v", err)
+	}
+	if _, err := decompress(compressed, 10); !errors.Is(err, ErrReadLimit) {
+		t.Errorf("期待 ErrReadLimit, 得到 %v", err)
+	}
+}
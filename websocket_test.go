@@ -0,0 +1,57 @@
+package kanggo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/7836246/kanggo/websocket"
+)
+
+// 测试通过路由注册 WebSocket 处理函数
+func TestWebSocketRoute(t *testing.T) {
+	app := New(Config{})
+	app.WebSocket("/ws", func(conn *websocket.Conn) {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(messageType, append([]byte("echo: "), data...))
+	})
+
+	server := httptest.NewServer(app.Router)
+	defer server.Close()
+
+	conn, _, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("KangGo")); err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("读取消息失败: %v", err)
+	}
+	if string(data) != "echo: KangGo" {
+		t.Errorf("响应内容错误: 得到 %v, 期待 %v", string(data), "echo: KangGo")
+	}
+
+	// 处理函数返回后服务端应正常关闭连接
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("期待正常关闭, 得到 %v", err)
+	}
+
+	// 普通 HTTP 请求访问 WebSocket 路由应返回 400
+	resp, err := http.Get(server.URL + "/ws")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("状态码错误: 得到 %v, 期待 %v", resp.StatusCode, http.StatusBadRequest)
+	}
+}